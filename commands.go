@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/jawher/mow.cli"
+)
+
+// validateConfig reports the problems found in the r/w configuration, without connecting to the database
+func validateConfig(out io.Writer, rwYml string) error {
+	rwConfig, err := config.ReadConfig(rwYml)
+	if err != nil {
+		fmt.Fprintf(out, "unable to read %s: %v\n", rwYml, err)
+		return err
+	}
+
+	if err = rwConfig.Validate(); err != nil {
+		if validationErr, ok := err.(*config.ValidationError); ok {
+			for _, problem := range validationErr.Problems {
+				fmt.Fprintln(out, problem)
+			}
+		} else {
+			fmt.Fprintln(out, err)
+		}
+		return err
+	}
+
+	fmt.Fprintf(out, "%s is valid\n", rwYml)
+	return nil
+}
+
+// printRoutes describes the endpoints, SQL statements and response headers for each path of the r/w configuration,
+// without connecting to the database
+func printRoutes(out io.Writer, rwYml string) error {
+	rwConfig, err := config.ReadConfig(rwYml)
+	if err != nil {
+		fmt.Fprintf(out, "unable to read %s: %v\n", rwYml, err)
+		return err
+	}
+
+	statements, err := db.DescribeStatements(rwConfig)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return err
+	}
+
+	paths := make([]string, 0, len(rwConfig.Paths))
+	for path := range rwConfig.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		mapping := rwConfig.Paths[path]
+		fmt.Fprintf(out, "%s (table %s)\n", path, mapping.Table)
+
+		fmt.Fprintln(out, "  routes:")
+		for _, r := range pathRoutes(path, mapping, nil, 0, 0) {
+			fmt.Fprintf(out, "    %-6s %s\n", r.method, r.path)
+		}
+
+		st := statements[mapping.Table]
+		fmt.Fprintln(out, "  sql:")
+		fmt.Fprintf(out, "    read:   %s\n", st.Read)
+		fmt.Fprintf(out, "    insert: %s\n", st.Insert)
+		fmt.Fprintf(out, "    update: %s\n", st.Update)
+		fmt.Fprintf(out, "    upsert: %s\n", st.Upsert)
+
+		if len(mapping.Response.Headers) > 0 {
+			headers := make([]string, 0, len(mapping.Response.Headers))
+			for header := range mapping.Response.Headers {
+				headers = append(headers, header)
+			}
+			sort.Strings(headers)
+
+			fmt.Fprintln(out, "  headers:")
+			for _, header := range headers {
+				fmt.Fprintf(out, "    %s: %s\n", header, mapping.Response.Headers[header])
+			}
+		}
+	}
+	return nil
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		cli.Exit(1)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfig(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	err := validateConfig(out, "./config.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, "./config.yml is valid\n", out.String())
+}
+
+func TestValidateConfigWithProblems(t *testing.T) {
+	f, err := ioutil.TempFile("", "config-*.yml")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(`paths:
+  "/things/:id":
+    table: things
+    columns:
+      uuid: ":id"
+    primaryKey: id
+`)
+	require.NoError(t, err)
+	f.Close()
+
+	out := &bytes.Buffer{}
+
+	err = validateConfig(out, f.Name())
+	assert.Error(t, err)
+	assert.Equal(t, "path /things/:id: no document column ($) is configured\npath /things/:id: primaryKey id is not a configured column\n", out.String())
+}
+
+func TestPrintRoutes(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	err := printRoutes(out, "./config.yml")
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "/drafts/content/:id (table draft_content)\n  routes:\n    GET    /drafts/content/:id\n")
+	assert.Contains(t, out.String(), "    PATCH  /published/content/:id/annotations\n")
+	assert.Contains(t, out.String(), "    read:   SELECT body,hash FROM draft_annotations WHERE uuid = ?\n")
+	assert.Contains(t, out.String(), "    X-Origin-System-Id: origin_system\n")
+}
@@ -1,38 +1,302 @@
 package config
 
 import (
-	"io/ioutil"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	ConflictPolicyOverwrite = "overwrite"
+	ConflictPolicyReject    = "reject"
+)
+
+// Column types, which values are converted to before they are written
+const (
+	ColumnTypeString   = "string"
+	ColumnTypeInt      = "int"
+	ColumnTypeBool     = "bool"
+	ColumnTypeDatetime = "datetime"
+	ColumnTypeJSON     = "json"
+)
+
 type Config struct {
 	Paths map[string]Mapping `yaml:"paths"`
 }
 
 type Mapping struct {
 	Table                string            `yaml:"table"`
-	Columns              map[string]string `yaml:"columns"`
-	PrimaryKey           string            `yaml:"primaryKey"`
+	Columns              map[string]Column `yaml:"columns"`
+	PrimaryKey           PrimaryKey        `yaml:"primaryKey"`
 	HasConflictDetection bool              `yaml:"hasConflictDetection"`
+	ConflictPolicy       string            `yaml:"conflictPolicy"`
+	SkipUnchanged        bool              `yaml:"skipUnchanged"`
+	History              bool              `yaml:"history"`
+	HistoryLimit         int               `yaml:"historyLimit"`
+	Query                QueryMapping      `yaml:"query"`
+	AllowDelete          bool              `yaml:"allowDelete"`
+	AllowBulkRead        bool              `yaml:"allowBulkRead"`
+	CollectionPath       string            `yaml:"collectionPath"`
+	List                 ListMapping       `yaml:"list"`
+	BulkWrite            BulkWriteMapping  `yaml:"bulkWrite"`
+	Create               CreateMapping     `yaml:"create"`
 	Response             ResponseMapping   `yaml:"response"`
+	// Consumes lists the media types, or ranges such as text/*, of the documents which may be written. Any media type is accepted if empty.
+	Consumes []string `yaml:"consumes"`
+	// Produces lists the media types documents are read as, the first being the type of documents without their own. Documents are read as application/json if empty.
+	Produces []string `yaml:"produces"`
+	// CompressBody stores documents gzipped, and base64 encoded. Their hash is still that of the uncompressed document.
+	CompressBody bool `yaml:"compressBody"`
+	// MaxBodySize limits the size of the body of a write request, instead of the service default
+	MaxBodySize ByteSize `yaml:"maxBodySize"`
+	// Schema is the JSON Schema file which documents are validated against before they are written, relative to the file declaring the path
+	Schema string `yaml:"schema"`
+
+	schema *Schema
+}
+
+// Column maps a column to an expression. Its values are written as they are, unless a type is declared.
+// A column without any other option may be configured as its expression only.
+type Column struct {
+	Expr string `yaml:"expr"`
+	Type string `yaml:"type,omitempty"`
+	// Format is the Go time layout of datetime values, in requests and in response headers
+	Format string `yaml:"format,omitempty"`
+	// Required rejects a write whose value is missing or empty, rather than writing it as it is
+	Required bool `yaml:"required,omitempty"`
+	// Default replaces a missing or empty value
+	Default string `yaml:"default,omitempty"`
+}
+
+func (c *Column) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var expr string
+	if err := unmarshal(&expr); err == nil {
+		*c = Column{Expr: expr}
+		return nil
+	}
+
+	type plainColumn Column
+	return unmarshal((*plainColumn)(c))
+}
+
+func (c Column) MarshalYAML() (interface{}, error) {
+	if c == (Column{Expr: c.Expr}) {
+		return c.Expr, nil
+	}
+
+	type plainColumn Column
+	return plainColumn(c), nil
+}
+
+// ParseValue converts a string value, such as the default of the column, to the type of the column.
+// An empty string is nil in a column that is not a string; a value is returned as it is if the column has no type.
+func (c Column) ParseValue(s string) (interface{}, error) {
+	if s == "" && c.Type != "" && c.Type != ColumnTypeString {
+		return nil, nil
+	}
+
+	switch c.Type {
+	case ColumnTypeInt:
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	case ColumnTypeBool:
+		return strconv.ParseBool(strings.TrimSpace(s))
+	case ColumnTypeDatetime:
+		t, err := time.Parse(c.parseFormat(), s)
+		if err != nil {
+			return nil, err
+		}
+		return t.UTC(), nil
+	case ColumnTypeJSON:
+		by, err := json.Marshal(s)
+		return string(by), err
+	}
+	return s, nil
+}
+
+func (c Column) parseFormat() string {
+	if c.Format != "" {
+		return c.Format
+	}
+	return time.RFC3339Nano
+}
+
+// DocumentSchema returns the JSON Schema loaded for the mapping, if it declares one
+func (m Mapping) DocumentSchema() *Schema {
+	return m.schema
+}
+
+// BodySizeLimit returns the maximum size of the body of a write request, which is the given default unless the mapping declares its own
+func (m Mapping) BodySizeLimit(defaultLimit ByteSize) ByteSize {
+	if m.MaxBodySize > 0 {
+		return m.MaxBodySize
+	}
+	return defaultLimit
+}
+
+// BulkWriteBodySizeLimit returns the maximum size of the body of a bulk write request, which is the limit of a write request unless bulk write declares its own
+func (m Mapping) BulkWriteBodySizeLimit(defaultLimit ByteSize) ByteSize {
+	if m.BulkWrite.MaxBodySize > 0 {
+		return m.BulkWrite.MaxBodySize
+	}
+	return m.BodySizeLimit(defaultLimit)
+}
+
+// BulkWriteTimeout returns the maximum duration of a bulk write request, which is the given default unless bulk write declares its own
+func (m Mapping) BulkWriteTimeout(defaultTimeout time.Duration) time.Duration {
+	if m.BulkWrite.Timeout > 0 {
+		return m.BulkWrite.Timeout
+	}
+	return defaultTimeout
+}
+
+// PrimaryKey lists the columns identifying a document. A single column may be configured on its own rather than as a list.
+type PrimaryKey []string
+
+func (k *PrimaryKey) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var col string
+	if err := unmarshal(&col); err == nil {
+		*k = PrimaryKey{col}
+		return nil
+	}
+
+	var cols []string
+	if err := unmarshal(&cols); err != nil {
+		return err
+	}
+	*k = cols
+	return nil
+}
+
+func (k PrimaryKey) MarshalYAML() (interface{}, error) {
+	if len(k) == 1 {
+		return k[0], nil
+	}
+	return []string(k), nil
+}
+
+// KeyParams returns the names of the route parameters identifying a document, in primary key order.
+// A document with a single primary key column is identified by :id; the columns of a composite primary key are each mapped to a route parameter.
+func (m Mapping) KeyParams() []string {
+	if len(m.PrimaryKey) <= 1 {
+		return []string{"id"}
+	}
+	params := make([]string, len(m.PrimaryKey))
+	for i, col := range m.PrimaryKey {
+		if expr := m.Columns[col].Expr; strings.HasPrefix(expr, ":") && !strings.HasPrefix(expr, ":"+QueryParamPrefix) {
+			params[i] = expr[1:]
+		}
+	}
+	return params
+}
+
+// QueryMapping declares the query string parameters that column expressions may refer to, as :query.name
+type QueryMapping struct {
+	Allowed  []string `yaml:"allowed"`
+	Required []string `yaml:"required"`
+}
+
+type ListMapping struct {
+	Enabled bool     `yaml:"enabled"`
+	Columns []string `yaml:"columns"`
+}
+
+type BulkWriteMapping struct {
+	Enabled   bool `yaml:"enabled"`
+	ChunkSize int  `yaml:"chunkSize"`
+	// MaxBodySize limits the size of the body of a bulk write request, instead of the limit of the path
+	MaxBodySize ByteSize `yaml:"maxBodySize"`
+	// Timeout limits the duration of a bulk write request, instead of the timeout of the service
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// CreateMapping enables POST requests on the collection path, which create documents with keys generated by the service
+type CreateMapping struct {
+	Enabled bool `yaml:"enabled"`
+	// ID is the expression generating the key of a created document, uuid() unless configured
+	ID string `yaml:"id"`
+}
+
+// DefaultCreateID is the expression generating the keys of created documents, unless configured otherwise
+const DefaultCreateID = "uuid()"
+
+// IDExpr returns the expression generating the key of a created document
+func (c CreateMapping) IDExpr() string {
+	if c.ID != "" {
+		return c.ID
+	}
+	return DefaultCreateID
 }
 
 type ResponseMapping struct {
 	Headers map[string]string `yaml:"headers"`
 }
 
-func ReadConfig(yml string) (*Config, error) {
-	by, err := ioutil.ReadFile(yml)
+// CollectionPathFor returns the path of the collection holding the documents mapped at the given path.
+// Unless configured explicitly, this is the parent of the document path, or empty if the document path has no parent.
+func (m Mapping) CollectionPathFor(path string) string {
+	if m.CollectionPath != "" {
+		return m.CollectionPath
+	}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// QueryParamPrefix prefixes the names of query string parameters among the request parameters, e.g. :query.name
+const QueryParamPrefix = "query."
+
+// Params returns the names of the declared query string parameters, required parameters being implicitly allowed
+func (q QueryMapping) Params() []string {
+	var params []string
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string{}, q.Allowed...), q.Required...) {
+		if !seen[name] {
+			seen[name] = true
+			params = append(params, name)
+		}
+	}
+	return params
+}
+
+// Version identifies the content of the configuration, regardless of the layout of the YAML it was read from
+func (c *Config) Version() string {
+	// map keys are marshalled in order, so equal configurations have equal versions
+	by, _ := yaml.Marshal(c)
+	h := sha256.New()
+	h.Write(by)
+	// a schema file may change without the YAML changing
+	for _, path := range c.sortedPaths() {
+		if schema := c.Paths[path].schema; schema != nil {
+			h.Write([]byte(path + "=" + schema.digest + "\n"))
+		}
+	}
+	sum := h.Sum(nil)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// ReadConfig reads the configuration at a location, which is either a YAML file, a directory of YAML files or a glob pattern.
+// The paths of every file, and of the files they include, are merged into a single configuration.
+func ReadConfig(location string) (*Config, error) {
+	files, err := configFiles(location)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg := &Config{make(map[string]Mapping)}
-	err = yaml.Unmarshal(by, cfg)
-	if err != nil {
-		cfg = nil
+	l := newLoader()
+	for _, file := range files {
+		if err = l.load(file); err != nil {
+			return nil, err
+		}
 	}
 
-	return cfg, err
+	if len(l.problems) > 0 {
+		return nil, &ValidationError{Problems: l.problems}
+	}
+	return l.cfg, nil
 }
@@ -2,8 +2,11 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
 )
 
 func TestReadConfig(t *testing.T) {
@@ -26,3 +29,152 @@ func TestReadConfigNotValid(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, cfg)
 }
+
+func TestCollectionPathFor(t *testing.T) {
+	assert.Equal(t, "/drafts/content", Mapping{}.CollectionPathFor("/drafts/content/:id"))
+	assert.Equal(t, "/drafts/annotations", Mapping{CollectionPath: "/drafts/annotations"}.CollectionPathFor("/drafts/content/:id/annotations"))
+	assert.Equal(t, "", Mapping{}.CollectionPathFor("things"))
+}
+
+func TestVersion(t *testing.T) {
+	cfg, err := ReadConfig("../config.yml")
+	require.NoError(t, err)
+	other, err := ReadConfig("../config.yml")
+	require.NoError(t, err)
+
+	assert.Len(t, cfg.Version(), 12)
+	assert.Equal(t, cfg.Version(), other.Version())
+
+	mapping := other.Paths["/drafts/content/:id"]
+	mapping.AllowDelete = !mapping.AllowDelete
+	other.Paths["/drafts/content/:id"] = mapping
+	assert.NotEqual(t, cfg.Version(), other.Version())
+}
+
+func TestBodySizeLimit(t *testing.T) {
+	assert.Equal(t, ByteSize(1024), Mapping{}.BodySizeLimit(1024))
+	assert.Equal(t, ByteSize(512), Mapping{MaxBodySize: 512}.BodySizeLimit(1024))
+
+	assert.Equal(t, ByteSize(512), Mapping{MaxBodySize: 512}.BulkWriteBodySizeLimit(1024))
+	assert.Equal(t, ByteSize(4096), Mapping{MaxBodySize: 512, BulkWrite: BulkWriteMapping{MaxBodySize: 4096}}.BulkWriteBodySizeLimit(1024))
+}
+
+func TestBulkWriteTimeout(t *testing.T) {
+	assert.Equal(t, 8*time.Second, Mapping{}.BulkWriteTimeout(8*time.Second))
+	assert.Equal(t, 2*time.Minute, Mapping{BulkWrite: BulkWriteMapping{Timeout: 2 * time.Minute}}.BulkWriteTimeout(8*time.Second))
+
+	var m Mapping
+	require.NoError(t, yaml.Unmarshal([]byte("bulkWrite:\n  timeout: 90s"), &m))
+	assert.Equal(t, 90*time.Second, m.BulkWrite.Timeout)
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]ByteSize{
+		"100":    100,
+		"100B":   100,
+		"512KB":  512 << 10,
+		"16 MB":  16 << 20,
+		"1gb":    1 << 30,
+		" 2MB  ": 2 << 20,
+	}
+	for s, expected := range tests {
+		size, err := ParseByteSize(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, size, s)
+	}
+
+	for _, s := range []string{"", "MB", "-1KB", "1.5MB", "16MiB"} {
+		_, err := ParseByteSize(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestByteSizeYAML(t *testing.T) {
+	var m Mapping
+	require.NoError(t, yaml.Unmarshal([]byte("maxBodySize: 2MB"), &m))
+	assert.Equal(t, ByteSize(2<<20), m.MaxBodySize)
+
+	require.NoError(t, yaml.Unmarshal([]byte("maxBodySize: 4096"), &m))
+	assert.Equal(t, ByteSize(4096), m.MaxBodySize)
+
+	assert.Error(t, yaml.Unmarshal([]byte("maxBodySize: lots"), &m))
+}
+
+func TestPrimaryKeyYAML(t *testing.T) {
+	var m Mapping
+	require.NoError(t, yaml.Unmarshal([]byte("primaryKey: uuid"), &m))
+	assert.Equal(t, PrimaryKey{"uuid"}, m.PrimaryKey)
+
+	require.NoError(t, yaml.Unmarshal([]byte("primaryKey: [uuid, platform]"), &m))
+	assert.Equal(t, PrimaryKey{"uuid", "platform"}, m.PrimaryKey)
+
+	out, err := yaml.Marshal(Mapping{PrimaryKey: PrimaryKey{"uuid"}})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "primaryKey: uuid\n")
+}
+
+func TestKeyParams(t *testing.T) {
+	m := Mapping{
+		Columns:    map[string]Column{"uuid": {Expr: ":uuid"}, "platform": {Expr: ":platform"}},
+		PrimaryKey: PrimaryKey{"uuid", "platform"},
+	}
+	assert.Equal(t, []string{"uuid", "platform"}, m.KeyParams())
+
+	m.PrimaryKey = PrimaryKey{"uuid"}
+	assert.Equal(t, []string{"id"}, m.KeyParams(), "a single column key is identified by :id")
+}
+
+func TestQueryParams(t *testing.T) {
+	q := QueryMapping{Allowed: []string{"brand", "edition"}, Required: []string{"edition", "region"}}
+	assert.Equal(t, []string{"brand", "edition", "region"}, q.Params())
+	assert.Empty(t, QueryMapping{}.Params())
+}
+
+func TestColumnParseValue(t *testing.T) {
+	v, err := Column{}.ParseValue("42")
+	assert.NoError(t, err)
+	assert.Equal(t, "42", v)
+
+	v, err = Column{Type: ColumnTypeInt}.ParseValue(" 42 ")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), v)
+
+	v, err = Column{Type: ColumnTypeInt}.ParseValue("")
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	v, err = Column{Type: ColumnTypeDatetime, Format: "02/01/2006"}.ParseValue("25/12/2017")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2017, 12, 25, 0, 0, 0, 0, time.UTC), v)
+
+	v, err = Column{Type: ColumnTypeJSON}.ParseValue("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, `"foo"`, v)
+
+	_, err = Column{Type: ColumnTypeBool}.ParseValue("yes please")
+	assert.Error(t, err)
+}
+
+func TestColumnYAML(t *testing.T) {
+	var columns map[string]Column
+	err := yaml.Unmarshal([]byte(`
+uuid: ":id"
+last_modified:
+  expr: "@._timestamp"
+  type: datetime
+  format: "2006-01-02T15:04:05Z07:00"
+`), &columns)
+	require.NoError(t, err)
+
+	assert.Equal(t, Column{Expr: ":id"}, columns["uuid"])
+	assert.Equal(t, Column{Expr: "@._timestamp", Type: ColumnTypeDatetime, Format: "2006-01-02T15:04:05Z07:00"}, columns["last_modified"])
+
+	by, err := yaml.Marshal(columns)
+	require.NoError(t, err)
+	assert.Equal(t, `last_modified:
+  expr: '@._timestamp'
+  type: datetime
+  format: 2006-01-02T15:04:05Z07:00
+uuid: :id
+`, string(by))
+}
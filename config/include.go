@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// configFile is the content of a single YAML file, whose paths are merged with those of the files it includes
+type configFile struct {
+	Include []string           `yaml:"include"`
+	Paths   map[string]Mapping `yaml:"paths"`
+}
+
+type pathSource struct {
+	path string
+	file string
+}
+
+// loader merges the paths of every file read, and records where each path and table came from
+type loader struct {
+	cfg      *Config
+	read     map[string]bool
+	paths    map[string]string
+	tables   map[string]pathSource
+	problems []string
+}
+
+func newLoader() *loader {
+	return &loader{
+		cfg:    &Config{Paths: make(map[string]Mapping)},
+		read:   make(map[string]bool),
+		paths:  make(map[string]string),
+		tables: make(map[string]pathSource),
+	}
+}
+
+// configFiles returns the YAML files at a location, which is either a file, a directory or a glob pattern, in name order
+func configFiles(location string) ([]string, error) {
+	if strings.ContainsAny(location, "*?[") {
+		files, err := filepath.Glob(location)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no configuration files match %s", location)
+		}
+		sort.Strings(files)
+		return files, nil
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{location}, nil
+	}
+
+	entries, err := ioutil.ReadDir(location)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			files = append(files, filepath.Join(location, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no configuration files in directory %s", location)
+	}
+	return files, nil
+}
+
+// load reads a file and the files it includes, relative to its own directory. A file that was already read is skipped.
+func (l *loader) load(file string) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	if l.read[abs] {
+		return nil
+	}
+	l.read[abs] = true
+
+	by, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	by, err = interpolate(by)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+
+	var content configFile
+	if err = yaml.Unmarshal(by, &content); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+
+	l.merge(file, content.Paths)
+
+	for _, include := range content.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(file), include)
+		}
+		files, err := configFiles(include)
+		if err != nil {
+			return fmt.Errorf("%s: unable to include %s: %v", file, include, err)
+		}
+		for _, f := range files {
+			if err = l.load(f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *loader) merge(file string, paths map[string]Mapping) {
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	for _, path := range sorted {
+		mapping := paths[path]
+		if other, found := l.paths[path]; found {
+			l.problems = append(l.problems, fmt.Sprintf("%s: path %s is already mapped in %s", file, path, other))
+			continue
+		}
+		// a table is mapped by a single path, as the service keeps a single mapping of each table
+		if other, found := l.tables[mapping.Table]; found && mapping.Table != "" {
+			l.problems = append(l.problems, fmt.Sprintf("%s: table %s of path %s is already mapped by path %s in %s", file, mapping.Table, path, other.path, other.file))
+			continue
+		}
+
+		if mapping.Schema != "" {
+			if !filepath.IsAbs(mapping.Schema) {
+				mapping.Schema = filepath.Join(filepath.Dir(file), mapping.Schema)
+			}
+			schema, err := loadSchema(mapping.Schema)
+			if err != nil {
+				l.problems = append(l.problems, fmt.Sprintf("%s: path %s: unable to load schema: %v", file, path, err))
+				continue
+			}
+			mapping.schema = schema
+		}
+
+		l.paths[path] = file
+		l.tables[mapping.Table] = pathSource{path, file}
+		l.cfg.Paths[path] = mapping
+	}
+}
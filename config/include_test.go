@@ -0,0 +1,168 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	thingsYml = `paths:
+  "/things/:id":
+    table: things
+    columns:
+      uuid: ":id"
+      body: "$"
+    primaryKey: uuid
+`
+	widgetsYml = `paths:
+  "/widgets/:id":
+    table: widgets
+    columns:
+      uuid: ":id"
+      body: "$"
+    primaryKey: uuid
+`
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestReadConfigDirectory(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"things.yml":   thingsYml,
+		"widgets.yaml": widgetsYml,
+		"README.md":    "not configuration",
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := ReadConfig(dir)
+	require.NoError(t, err)
+	assert.Len(t, cfg.Paths, 2)
+	assert.Equal(t, "things", cfg.Paths["/things/:id"].Table)
+	assert.Equal(t, "widgets", cfg.Paths["/widgets/:id"].Table)
+}
+
+func TestReadConfigGlob(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"things.yml":  thingsYml,
+		"widgets.yml": widgetsYml,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := ReadConfig(filepath.Join(dir, "wid*.yml"))
+	require.NoError(t, err)
+	assert.Len(t, cfg.Paths, 1)
+	assert.Contains(t, cfg.Paths, "/widgets/:id")
+
+	_, err = ReadConfig(filepath.Join(dir, "gadgets*.yml"))
+	assert.EqualError(t, err, "no configuration files match "+filepath.Join(dir, "gadgets*.yml"))
+}
+
+func TestReadConfigIncludes(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yml":        "include:\n  - teams/*.yml\n  - shared.yml\n" + thingsYml,
+		"teams/widgets.yml": widgetsYml,
+		"teams/gadgets.yml": "include: [../shared.yml]\n" + `paths:
+  "/gadgets/:id":
+    table: gadgets
+    columns:
+      uuid: ":id"
+      body: "$"
+    primaryKey: uuid
+`,
+		"shared.yml": `paths:
+  "/shared/:id":
+    table: shared
+    columns:
+      uuid: ":id"
+      body: "$"
+    primaryKey: uuid
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := ReadConfig(filepath.Join(dir, "config.yml"))
+	require.NoError(t, err)
+	assert.Len(t, cfg.Paths, 4, "a file included more than once is read once")
+	for _, path := range []string{"/things/:id", "/widgets/:id", "/gadgets/:id", "/shared/:id"} {
+		assert.Contains(t, cfg.Paths, path)
+	}
+}
+
+func TestReadConfigIncludeNotFound(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yml": "include: [missing.yml]\n" + thingsYml,
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := ReadConfig(filepath.Join(dir, "config.yml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "config.yml")+": unable to include "+filepath.Join(dir, "missing.yml"))
+}
+
+func TestReadConfigInvalidYAMLNamesFile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"things.yml":  thingsYml,
+		"widgets.yml": "paths: [",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := ReadConfig(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), filepath.Join(dir, "widgets.yml")+": yaml:")
+}
+
+func TestReadConfigDuplicates(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yml": thingsYml,
+		"b.yml": thingsYml,
+		"c.yml": `paths:
+  "/other-things/:id":
+    table: things
+    columns:
+      uuid: ":id"
+      body: "$"
+    primaryKey: uuid
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := ReadConfig(dir)
+	require.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "b.yml") + ": path /things/:id is already mapped in " + filepath.Join(dir, "a.yml"),
+		filepath.Join(dir, "c.yml") + ": table things of path /other-things/:id is already mapped by path /things/:id in " + filepath.Join(dir, "a.yml"),
+	}, err.(*ValidationError).Problems)
+}
+
+func TestReadConfigDuplicateTableInOneFile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yml": thingsYml + `  "/other-things/:id":
+    table: things
+    columns:
+      uuid: ":id"
+      body: "$"
+    primaryKey: uuid
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := ReadConfig(filepath.Join(dir, "config.yml"))
+	require.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "config.yml") + ": table things of path /things/:id is already mapped by path /other-things/:id in " + filepath.Join(dir, "config.yml"),
+	}, err.(*ValidationError).Problems)
+}
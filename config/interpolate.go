@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const secretFilePrefix = "file:"
+
+var (
+	// a reference, or an escaped reference ($${) that is left as it is
+	referencePattern = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+	variablePattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// interpolate replaces references in the string values, and keys, of the YAML document with their values:
+//
+//	${VAR} - the value of the environment variable VAR, which must be set
+//	${VAR:-default} - the value of VAR, or default if VAR is unset or empty
+//	${file:path} - the content of the file, without trailing new lines (e.g. a mounted secret)
+//
+// $${ is replaced by a literal ${. The document is parsed first, so that a value is never parsed as YAML itself,
+// but a value consisting of a single reference is a bool or an integer if its value is one, e.g. allowDelete: ${ALLOW_DELETE:-true}
+func interpolate(yml []byte) ([]byte, error) {
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(yml, &doc); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	interpolated := interpolateValue(doc, "", &problems)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("unable to interpolate configuration: %s", strings.Join(problems, "; "))
+	}
+	return yaml.Marshal(interpolated)
+}
+
+// interpolateValue replaces the references in a decoded value, problems being reported with the location of the value, e.g. paths./things/:id.table
+func interpolateValue(v interface{}, location string, problems *[]string) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		for i, item := range v {
+			key := fmt.Sprint(item.Key)
+			if s, ok := item.Key.(string); ok {
+				key = interpolateString(s, location, problems)
+				item.Key = key
+			}
+			if location != "" {
+				key = location + "." + key
+			}
+			item.Value = interpolateValue(item.Value, key, problems)
+			v[i] = item
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = interpolateValue(item, fmt.Sprintf("%s[%d]", location, i), problems)
+		}
+		return v
+	case string:
+		val := interpolateString(v, location, problems)
+		if ref := referencePattern.FindString(v); ref == v && ref != "$${" {
+			return scalarValue(val)
+		}
+		return val
+	}
+	return v
+}
+
+func interpolateString(s string, location string, problems *[]string) string {
+	return referencePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+
+		val, err := resolve(ref[2 : len(ref)-1])
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: %v", location, err))
+			return ref
+		}
+		return val
+	})
+}
+
+// scalarValue returns the bool or integer a value is, or the value itself.
+// Only values that are written the same way as a bool or an integer are converted, so that e.g. 007 remains a string.
+func scalarValue(s string) interface{} {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return s
+	}
+	switch v.(type) {
+	case bool, int, int64, uint64:
+		if fmt.Sprint(v) == s {
+			return v
+		}
+	}
+	return s
+}
+
+func resolve(ref string) (string, error) {
+	if strings.HasPrefix(ref, secretFilePrefix) {
+		path := ref[len(secretFilePrefix):]
+		by, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read secret file %s: %v", path, err)
+		}
+		return strings.TrimRight(string(by), "\r\n"), nil
+	}
+
+	name := ref
+	def, hasDefault := "", false
+	if i := strings.Index(ref, ":-"); i >= 0 {
+		name, def, hasDefault = ref[:i], ref[i+2:], true
+	}
+
+	if !variablePattern.MatchString(name) {
+		return "", fmt.Errorf("${%s} is not a valid reference", ref)
+	}
+
+	val, found := os.LookupEnv(name)
+	if val == "" && hasDefault {
+		return def, nil
+	}
+	if !found {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return val, nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	os.Setenv("RW_TEST_TABLE", "draft_content_staging")
+	defer os.Unsetenv("RW_TEST_TABLE")
+	os.Setenv("RW_TEST_EMPTY", "")
+	defer os.Unsetenv("RW_TEST_EMPTY")
+
+	secret, err := ioutil.TempFile("", "secret")
+	require.NoError(t, err)
+	defer os.Remove(secret.Name())
+	secret.WriteString("s3cr3t\n")
+	secret.Close()
+
+	os.Setenv("RW_TEST_YAML", "a: b # c\n- d")
+	defer os.Unsetenv("RW_TEST_YAML")
+
+	tests := []struct {
+		name     string
+		yml      string
+		expected string
+	}{
+		{"variable", "table: ${RW_TEST_TABLE}", "table: draft_content_staging\n"},
+		{"variable in text", `body: "$.${RW_TEST_TABLE}.body"`, "body: $.draft_content_staging.body\n"},
+		{"default of set variable", "table: ${RW_TEST_TABLE:-draft_content}", "table: draft_content_staging\n"},
+		{"default of unset variable", "table: ${RW_TEST_UNSET:-draft_content}", "table: draft_content\n"},
+		{"default of empty variable", "table: ${RW_TEST_EMPTY:-draft_content}", "table: draft_content\n"},
+		{"empty default", "origin: '${RW_TEST_UNSET:-}'", "origin: \"\"\n"},
+		{"empty variable", "origin: ${RW_TEST_EMPTY}", "origin: \"\"\n"},
+		{"secret file", "key: ${file:" + secret.Name() + "}", "key: s3cr3t\n"},
+		{"escaped reference", "literal: $${RW_TEST_TABLE}", "literal: ${RW_TEST_TABLE}\n"},
+		{"document expressions", "body: \"$\"\nfoo: \"$.foo\"", "body: $\nfoo: $.foo\n"},
+		{"comment", "# ${RW_TEST_UNSET}\ntable: x", "table: x\n"},
+		{"value containing YAML", "origin: ${RW_TEST_YAML}\ntable: x", "origin: |-\n  a: b # c\n  - d\ntable: x\n"},
+		{"quoted value containing YAML", `origin: "${RW_TEST_YAML}"`, "origin: |-\n  a: b # c\n  - d\n"},
+		{"bool", "allowDelete: ${RW_TEST_UNSET:-true}", "allowDelete: true\n"},
+		{"integer", "historyLimit: ${RW_TEST_UNSET:-10}", "historyLimit: 10\n"},
+		{"string that is not written as an integer", "table: ${RW_TEST_UNSET:-007}", "table: \"007\"\n"},
+		{"key", "paths:\n  /${RW_TEST_TABLE}/:id:\n    table: x", "paths:\n  /draft_content_staging/:id:\n    table: x\n"},
+		{"list", "include: [\"${RW_TEST_TABLE}.yml\"]", "include:\n- draft_content_staging.yml\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := interpolate([]byte(test.yml))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(actual))
+		})
+	}
+}
+
+func TestInterpolateProblems(t *testing.T) {
+	_, err := interpolate([]byte("paths:\n  /things/:id:\n    table: ${RW_TEST_UNSET}\ninclude:\n  - ${file:/no/such/secret}\nbad: ${not-a-var}"))
+	assert.EqualError(t, err, "unable to interpolate configuration: "+
+		"paths./things/:id.table: environment variable RW_TEST_UNSET is not set; "+
+		"include[0]: unable to read secret file /no/such/secret: open /no/such/secret: no such file or directory; "+
+		"bad: ${not-a-var} is not a valid reference")
+}
+
+func TestReadConfigInterpolated(t *testing.T) {
+	os.Setenv("RW_TEST_TABLE", "draft_content_staging")
+	defer os.Unsetenv("RW_TEST_TABLE")
+	os.Setenv("RW_TEST_ORIGIN", "cct: legacy # migrated")
+	defer os.Unsetenv("RW_TEST_ORIGIN")
+
+	f, err := ioutil.TempFile("", "config-*.yml")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString(`paths:
+  "/drafts/content/:id":
+    table: ${RW_TEST_TABLE}
+    columns:
+      uuid: ":id"
+      origin_system: ${RW_TEST_ORIGIN:-cct}
+      body: "$"
+    primaryKey: uuid
+    allowDelete: ${RW_TEST_ALLOW_DELETE:-true}
+`)
+	f.Close()
+
+	cfg, err := ReadConfig(f.Name())
+	require.NoError(t, err)
+
+	mapping := cfg.Paths["/drafts/content/:id"]
+	assert.Equal(t, "draft_content_staging", mapping.Table)
+	assert.Equal(t, "cct: legacy # migrated", mapping.Columns["origin_system"].Expr)
+	assert.True(t, mapping.AllowDelete)
+}
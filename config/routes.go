@@ -0,0 +1,57 @@
+package config
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Route is an endpoint created for a path of the configuration
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Routes returns the endpoints created for the documents mapped at the given path, including the endpoints of its collection path
+func (m Mapping) Routes(path string) []Route {
+	routes := []Route{
+		{http.MethodGet, path},
+		{http.MethodPut, path},
+		{http.MethodPatch, path},
+	}
+	if m.AllowDelete {
+		routes = append(routes, Route{http.MethodDelete, path})
+	}
+	if m.History {
+		routes = append(routes,
+			Route{http.MethodGet, path + "/__history"},
+			Route{http.MethodGet, path + "/__history/:hash"},
+			Route{http.MethodPost, path + "/__history/:hash/restore"},
+		)
+	}
+
+	collectionPath := m.CollectionPathFor(path)
+	if m.List.Enabled {
+		routes = append(routes, Route{http.MethodGet, collectionPath})
+	}
+	if m.AllowBulkRead {
+		routes = append(routes, Route{http.MethodPost, collectionPath + "/__bulk-read"})
+	}
+	if m.Create.Enabled {
+		routes = append(routes, Route{http.MethodPost, collectionPath})
+	}
+	if m.BulkWrite.Enabled {
+		routes = append(routes, Route{http.MethodPost, collectionPath + "/__bulk-write"})
+	}
+	return routes
+}
+
+// routeKey identifies the requests matched by a route, whatever the names of its parameters, e.g. GET /things/: for GET /things/:id
+func (r Route) routeKey() string {
+	segments := strings.Split(r.Path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = ":"
+		}
+	}
+	return r.Method + " " + strings.Join(segments, "/")
+}
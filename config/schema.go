@@ -0,0 +1,55 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Schema is a JSON Schema, which the documents written at a path are validated against
+type Schema struct {
+	digest string
+	schema *gojsonschema.Schema
+}
+
+// loadSchema reads and compiles a JSON Schema file
+func loadSchema(file string) (*Schema, error) {
+	by, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := NewSchema(by)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid JSON Schema: %v", file, err)
+	}
+	return schema, nil
+}
+
+// NewSchema compiles a JSON Schema
+func NewSchema(by []byte) (*Schema, error) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(by))
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(by)
+	return &Schema{digest: hex.EncodeToString(sum[:]), schema: schema}, nil
+}
+
+// Validate returns the violations of the schema by a document, or an error if the document is not JSON
+func (s *Schema) Validate(doc []byte) ([]string, error) {
+	result, err := s.schema.Validate(gojsonschema.NewBytesLoader(doc))
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []string
+	for _, e := range result.Errors() {
+		violations = append(violations, e.String())
+	}
+	return violations, nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	thingSchema = `{
+  "type": "object",
+  "properties": {"title": {"type": "string"}},
+  "required": ["title"]
+}`
+	thingsWithSchemaYml = `paths:
+  "/things/:id":
+    table: things
+    columns:
+      uuid: ":id"
+      body: "$"
+    primaryKey: uuid
+    schema: schemas/thing.json
+`
+)
+
+func TestReadConfigSchema(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"things.yml":         thingsWithSchemaYml,
+		"schemas/thing.json": thingSchema,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := ReadConfig(filepath.Join(dir, "things.yml"))
+	require.NoError(t, err)
+
+	mapping := cfg.Paths["/things/:id"]
+	assert.Equal(t, filepath.Join(dir, "schemas/thing.json"), mapping.Schema, "schema is relative to the declaring file")
+	require.NotNil(t, mapping.DocumentSchema())
+
+	violations, err := mapping.DocumentSchema().Validate([]byte(`{"title": "a thing"}`))
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+
+	violations, err = mapping.DocumentSchema().Validate([]byte(`{"title": 1}`))
+	assert.NoError(t, err)
+	assert.Len(t, violations, 1)
+
+	_, err = mapping.DocumentSchema().Validate([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestReadConfigWithoutSchema(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"things.yml": thingsYml})
+	defer os.RemoveAll(dir)
+
+	cfg, err := ReadConfig(filepath.Join(dir, "things.yml"))
+	require.NoError(t, err)
+	assert.Nil(t, cfg.Paths["/things/:id"].DocumentSchema())
+}
+
+func TestReadConfigSchemaProblems(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"things.yml":         thingsWithSchemaYml,
+		"schemas/thing.json": `{"type": 1}`,
+		"widgets.yml":        widgetsYml + "    schema: missing.json\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := ReadConfig(dir)
+	require.IsType(t, &ValidationError{}, err)
+	problems := err.(*ValidationError).Problems
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0], "things.yml: path /things/:id: unable to load schema: ")
+	assert.Contains(t, problems[0], "is not a valid JSON Schema")
+	assert.Contains(t, problems[1], "widgets.yml: path /widgets/:id: unable to load schema: ")
+}
+
+func TestVersionChangesWithSchema(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"things.yml":         thingsWithSchemaYml,
+		"schemas/thing.json": thingSchema,
+	})
+	defer os.RemoveAll(dir)
+
+	cfg, err := ReadConfig(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "schemas/thing.json"), []byte(`{"type": "object"}`), 0644))
+	changed, err := ReadConfig(dir)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, cfg.Version(), changed.Version())
+}
@@ -0,0 +1,345 @@
+package config
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/Financial-Times/generic-rw-aurora/expression"
+)
+
+// hashColumn is the column holding the document hash, which every table has in addition to its configured columns
+const hashColumn = "hash"
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d problem(s) found in r/w configuration: %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// Validate checks every path mapping against its route pattern and the routes of the other paths, and returns a *ValidationError listing all the problems found, if any
+func (c *Config) Validate() error {
+	var problems []string
+	for _, path := range c.sortedPaths() {
+		for _, problem := range c.Paths[path].validate(path) {
+			problems = append(problems, fmt.Sprintf("path %s: %s", path, problem))
+		}
+	}
+	problems = append(problems, c.validateRoutes()...)
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateRoutes reports the endpoints created by more than one path, or more than once by a path, as only one of them would be routed to
+func (c *Config) validateRoutes() []string {
+	var routes []Route
+	paths := make(map[string][]string)
+	for _, path := range c.sortedPaths() {
+		for _, route := range c.Paths[path].Routes(path) {
+			key := route.routeKey()
+			if _, found := paths[key]; !found {
+				routes = append(routes, route)
+			}
+			paths[key] = append(paths[key], path)
+		}
+	}
+
+	var problems []string
+	for _, route := range routes {
+		if routePaths := paths[route.routeKey()]; len(routePaths) > 1 {
+			problems = append(problems, fmt.Sprintf("route %s %s is created more than once, by paths %s", route.Method, route.Path, strings.Join(routePaths, ", ")))
+		}
+	}
+	return problems
+}
+
+func (c *Config) sortedPaths() []string {
+	paths := make([]string, 0, len(c.Paths))
+	for path := range c.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (m Mapping) validate(path string) []string {
+	var problems []string
+	problemf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	params := routeParams(path)
+	for _, name := range m.Query.Params() {
+		if name == "" {
+			problemf("query parameter names must not be empty")
+		}
+		params[QueryParamPrefix+name] = true
+	}
+	if !strings.HasPrefix(path, "/") {
+		problemf("path must start with /")
+	}
+	if len(m.PrimaryKey) <= 1 && !params["id"] {
+		problemf("route has no :id parameter for the document key")
+	}
+
+	if m.Table == "" {
+		problemf("table is not configured")
+	}
+
+	var docColumns []string
+	for _, col := range m.sortedColumns() {
+		if col == hashColumn {
+			problemf("column %s is reserved for the document hash", col)
+			continue
+		}
+
+		column := m.Columns[col]
+		switch column.Type {
+		case "", ColumnTypeString, ColumnTypeInt, ColumnTypeBool, ColumnTypeJSON:
+			if column.Format != "" {
+				problemf("column %s has a format, which only applies to %s columns", col, ColumnTypeDatetime)
+			}
+		case ColumnTypeDatetime:
+		default:
+			problemf("column %s has type %s, which is not one of %s, %s, %s, %s, %s", col, column.Type, ColumnTypeString, ColumnTypeInt, ColumnTypeBool, ColumnTypeDatetime, ColumnTypeJSON)
+		}
+
+		if column.Required && column.Default != "" {
+			problemf("column %s is required and has a default value, so its value could never be missing", col)
+		}
+		if column.Default != "" {
+			if _, err := column.ParseValue(column.Default); err != nil {
+				problemf("column %s has an invalid default value %q: %v", col, column.Default, err)
+			}
+		}
+
+		expr, err := expression.Parse(column.Expr)
+		if err != nil {
+			problemf("column %s has an invalid expression %q: %v", col, column.Expr, err)
+			continue
+		}
+		if expression.IsDocument(expr) {
+			docColumns = append(docColumns, col)
+			if column.Type != "" && column.Type != ColumnTypeString {
+				problemf("column %s holds the document ($), which can only have type %s", col, ColumnTypeString)
+			}
+		}
+		for _, param := range expression.Params(expr) {
+			if !params[param] {
+				problemf("column %s refers to parameter :%s, which is not in the route or a declared query parameter", col, param)
+			}
+		}
+	}
+	if len(docColumns) == 0 {
+		problemf("no document column ($) is configured")
+	} else if len(docColumns) > 1 {
+		problemf("more than one document column ($) is configured: %s", strings.Join(docColumns, ", "))
+	}
+
+	if len(m.PrimaryKey) == 0 || m.PrimaryKey[0] == "" {
+		problemf("primaryKey is not configured")
+	}
+	seen := make(map[string]bool)
+	for i, col := range m.PrimaryKey {
+		if seen[col] {
+			problemf("primaryKey column %s is listed more than once", col)
+			continue
+		}
+		seen[col] = true
+		if _, found := m.Columns[col]; !found {
+			if col != "" {
+				problemf("primaryKey %s is not a configured column", col)
+			}
+			continue
+		}
+		// a composite key is read from the route, one parameter per column
+		if len(m.PrimaryKey) > 1 {
+			if param := m.KeyParams()[i]; param == "" || !params[param] {
+				problemf("primaryKey column %s is mapped to %q, rather than to a parameter of the route", col, m.Columns[col].Expr)
+			}
+		}
+	}
+
+	switch m.ConflictPolicy {
+	case "", ConflictPolicyOverwrite, ConflictPolicyReject:
+	default:
+		problemf("conflictPolicy %s is not one of %s, %s", m.ConflictPolicy, ConflictPolicyOverwrite, ConflictPolicyReject)
+	}
+
+	if m.HistoryLimit < 0 {
+		problemf("historyLimit must not be negative")
+	} else if m.HistoryLimit > 0 && !m.History {
+		problemf("historyLimit is set but history is not enabled")
+	}
+
+	if m.CollectionPath != "" && !strings.HasPrefix(m.CollectionPath, "/") {
+		problemf("collectionPath must start with /")
+	}
+
+	if m.Create.Enabled {
+		m.validateCreate(path, params, problemf)
+	}
+
+	if endpoints := m.collectionEndpoints(); len(endpoints) > 0 || m.Create.Enabled {
+		collectionPath := m.CollectionPathFor(path)
+		if collectionParams := routeParams(collectionPath); len(collectionParams) > 0 {
+			for _, endpoint := range endpoints {
+				problemf("%s is enabled, but the collection path %s has parameters (:%s), which would not restrict the documents it applies to", endpoint, collectionPath, strings.Join(sortedParams(collectionParams), ", :"))
+			}
+		}
+		// a document path at the root, e.g. /:id, has no parent to route the collection endpoints at
+		if collectionPath == "" {
+			if m.Create.Enabled {
+				endpoints = append(endpoints, "create")
+			}
+			for _, endpoint := range endpoints {
+				problemf("%s is enabled, but the path has no parent for the collection endpoints, and no collectionPath is configured", endpoint)
+			}
+		}
+	}
+
+	for _, col := range m.List.Columns {
+		if !m.hasColumn(col) {
+			problemf("list column %s is not a configured column", col)
+		}
+	}
+
+	if m.BulkWrite.ChunkSize < 0 {
+		problemf("bulkWrite.chunkSize must not be negative")
+	}
+	if m.BulkWrite.Timeout < 0 {
+		problemf("bulkWrite.timeout must not be negative")
+	}
+
+	for _, mediaType := range m.Consumes {
+		if !isMediaType(mediaType) {
+			problemf("consumes %q is not a valid media type", mediaType)
+		}
+	}
+	for _, mediaType := range m.Produces {
+		if !isMediaType(mediaType) {
+			problemf("produces %q is not a valid media type", mediaType)
+		} else if strings.Contains(mediaType, "*") {
+			problemf("produces %s is a media range, rather than the media type of documents", mediaType)
+		}
+	}
+
+	for _, header := range sortedKeys(m.Response.Headers) {
+		if col := m.Response.Headers[header]; !m.hasColumn(col) {
+			problemf("response header %s refers to column %s, which is not a configured column", header, col)
+		}
+	}
+
+	return problems
+}
+
+// collectionEndpoints returns the enabled endpoints of the collection path that apply to every document of the table
+func (m Mapping) collectionEndpoints() []string {
+	var endpoints []string
+	if m.List.Enabled {
+		endpoints = append(endpoints, "list")
+	}
+	if m.AllowBulkRead {
+		endpoints = append(endpoints, "allowBulkRead")
+	}
+	if m.BulkWrite.Enabled {
+		endpoints = append(endpoints, "bulkWrite")
+	}
+	return endpoints
+}
+
+// validateCreate checks that the key of a created document, and the path of the document, can be derived from a request on the collection path
+func (m Mapping) validateCreate(path string, params map[string]bool, problemf func(format string, args ...interface{})) {
+	generatesKey := false
+	for _, param := range m.KeyParams() {
+		generatesKey = generatesKey || param == "id"
+	}
+	if !generatesKey {
+		problemf("create is enabled, but :id, which is generated, is not a parameter of the primary key")
+	}
+
+	collectionParams := routeParams(m.CollectionPathFor(path))
+	for _, name := range m.Query.Params() {
+		collectionParams[QueryParamPrefix+name] = true
+	}
+	for _, param := range sortedParams(params) {
+		if param != "id" && !strings.HasPrefix(param, QueryParamPrefix) && !collectionParams[param] {
+			problemf("create is enabled, but the collection path %s has no :%s parameter for the path of created documents", m.CollectionPathFor(path), param)
+		}
+	}
+
+	expr, err := expression.Parse(m.Create.IDExpr())
+	if err != nil {
+		problemf("create.id has an invalid expression %q: %v", m.Create.IDExpr(), err)
+		return
+	}
+	if expression.IsDocument(expr) {
+		problemf("create.id cannot be the whole document ($)")
+	}
+	for _, param := range expression.Params(expr) {
+		if !collectionParams[param] {
+			problemf("create.id refers to parameter :%s, which is not in the collection path or a declared query parameter", param)
+		}
+	}
+}
+
+// hasColumn returns whether the column is configured, or is the hash column
+func (m Mapping) hasColumn(col string) bool {
+	if col == hashColumn {
+		return true
+	}
+	_, found := m.Columns[col]
+	return found
+}
+
+// isMediaType returns whether a value is a media type or range, with optional parameters, e.g. text/html; charset=utf-8
+func isMediaType(value string) bool {
+	mediaType, _, err := mime.ParseMediaType(value)
+	return err == nil && strings.Count(mediaType, "/") == 1 && !strings.HasPrefix(mediaType, "/") && !strings.HasSuffix(mediaType, "/")
+}
+
+// routeParams returns the names of the :param placeholders in a route pattern
+func routeParams(path string) map[string]bool {
+	params := make(map[string]bool)
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			params[segment[1:]] = true
+		}
+	}
+	return params
+}
+
+func sortedParams(params map[string]bool) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedColumns returns the names of the configured columns in order
+func (m Mapping) sortedColumns() []string {
+	cols := make([]string, 0, len(m.Columns))
+	for col := range m.Columns {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	return cols
+}
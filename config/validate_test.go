@@ -0,0 +1,309 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validMapping() Mapping {
+	return Mapping{
+		Table: "draft_content",
+		Columns: map[string]Column{
+			"uuid":          {Expr: ":id"},
+			"origin_system": {Expr: "@.x-origin-system-id"},
+			"body":          {Expr: "$"},
+		},
+		PrimaryKey: PrimaryKey{"uuid"},
+		Response: ResponseMapping{
+			Headers: map[string]string{"X-Origin-System-Id": "origin_system"},
+		},
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	cfg, err := ReadConfig("../config.yml")
+	require.NoError(t, err)
+
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		modify   func(m *Mapping)
+		problems []string
+	}{
+		{"valid", "/drafts/content/:id", func(m *Mapping) {}, nil},
+		{"no document column", "/drafts/content/:id", func(m *Mapping) {
+			delete(m.Columns, "body")
+		}, []string{"path /drafts/content/:id: no document column ($) is configured"}},
+		{"two document columns", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["copy"] = Column{Expr: "$"}
+		}, []string{"path /drafts/content/:id: more than one document column ($) is configured: body, copy"}},
+		{"unknown primary key", "/drafts/content/:id", func(m *Mapping) {
+			m.PrimaryKey = PrimaryKey{"id"}
+		}, []string{"path /drafts/content/:id: primaryKey id is not a configured column"}},
+		{"unknown response header column", "/drafts/content/:id", func(m *Mapping) {
+			m.Response.Headers["Content-Type"] = "content_type"
+		}, []string{"path /drafts/content/:id: response header Content-Type refers to column content_type, which is not a configured column"}},
+		{"hash response header column", "/drafts/content/:id", func(m *Mapping) {
+			m.Response.Headers["X-Hash"] = "hash"
+		}, nil},
+		{"unknown route parameter", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["brand"] = Column{Expr: ":brand"}
+		}, []string{"path /drafts/content/:id: column brand refers to parameter :brand, which is not in the route or a declared query parameter"}},
+		{"declared query parameter", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["brand"] = Column{Expr: ":query.brand"}
+			m.Columns["edition"] = Column{Expr: ":query.edition"}
+			m.Query = QueryMapping{Allowed: []string{"brand"}, Required: []string{"edition"}}
+		}, nil},
+		{"undeclared query parameter", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["brand"] = Column{Expr: ":query.brand"}
+		}, []string{"path /drafts/content/:id: column brand refers to parameter :query.brand, which is not in the route or a declared query parameter"}},
+		{"function expressions", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["created"] = Column{Expr: "now()"}
+			m.Columns["origin_system"] = Column{Expr: `coalesce(lower(@.x-origin-system-id), "unknown")`}
+			m.Columns["body_hash"] = Column{Expr: "hash($)"}
+		}, nil},
+		{"invalid expression", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["origin_system"] = Column{Expr: "lower(@.x-origin-system-id"}
+		}, []string{`path /drafts/content/:id: column origin_system has an invalid expression "lower(@.x-origin-system-id": missing ) after arguments of lower() at position 27`}},
+		{"unknown parameter in a function", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["version"] = Column{Expr: `concat(:id, "-", :version)`}
+		}, []string{"path /drafts/content/:id: column version refers to parameter :version, which is not in the route or a declared query parameter"}},
+		{"typed columns", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["version"] = Column{Expr: "$.version", Type: ColumnTypeInt}
+			m.Columns["published"] = Column{Expr: "$.published", Type: ColumnTypeDatetime, Format: "2006-01-02"}
+		}, nil},
+		{"unknown column type", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["version"] = Column{Expr: "$.version", Type: "integer"}
+		}, []string{"path /drafts/content/:id: column version has type integer, which is not one of string, int, bool, datetime, json"}},
+		{"format of a column that is not a datetime", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["version"] = Column{Expr: "$.version", Type: ColumnTypeInt, Format: "%d"}
+		}, []string{"path /drafts/content/:id: column version has a format, which only applies to datetime columns"}},
+		{"typed document column", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["body"] = Column{Expr: "$", Type: ColumnTypeJSON}
+		}, []string{"path /drafts/content/:id: column body holds the document ($), which can only have type string"}},
+		{"required and default columns", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["origin_system"] = Column{Expr: "@.x-origin-system-id", Required: true}
+			m.Columns["type"] = Column{Expr: "$.type", Default: "Article"}
+		}, nil},
+		{"required column with a default", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["type"] = Column{Expr: "$.type", Required: true, Default: "Article"}
+		}, []string{"path /drafts/content/:id: column type is required and has a default value, so its value could never be missing"}},
+		{"typed column defaults", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["version"] = Column{Expr: "$.version", Type: ColumnTypeInt, Default: "1"}
+			m.Columns["published"] = Column{Expr: "$.published", Type: ColumnTypeDatetime, Format: "2006-01-02", Default: "2018-01-01"}
+		}, nil},
+		{"typed column with an invalid default", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["version"] = Column{Expr: "$.version", Type: ColumnTypeInt, Default: "abc"}
+			m.Columns["draft"] = Column{Expr: "$.draft", Type: ColumnTypeBool, Default: "maybe"}
+		}, []string{
+			`path /drafts/content/:id: column draft has an invalid default value "maybe": strconv.ParseBool: parsing "maybe": invalid syntax`,
+			`path /drafts/content/:id: column version has an invalid default value "abc": strconv.ParseInt: parsing "abc": invalid syntax`,
+		}},
+		{"no id parameter", "/drafts/content/:uuid", func(m *Mapping) {
+			m.Columns["uuid"] = Column{Expr: ":uuid"}
+		}, []string{"path /drafts/content/:uuid: route has no :id parameter for the document key"}},
+		{"reserved hash column", "/drafts/content/:id", func(m *Mapping) {
+			m.Columns["hash"] = Column{Expr: "@.hash"}
+		}, []string{"path /drafts/content/:id: column hash is reserved for the document hash"}},
+		{"unknown conflict policy", "/drafts/content/:id", func(m *Mapping) {
+			m.ConflictPolicy = "merge"
+		}, []string{"path /drafts/content/:id: conflictPolicy merge is not one of overwrite, reject"}},
+		{"history limit without history", "/drafts/content/:id", func(m *Mapping) {
+			m.HistoryLimit = 5
+		}, []string{"path /drafts/content/:id: historyLimit is set but history is not enabled"}},
+		{"unknown list column", "/drafts/content/:id", func(m *Mapping) {
+			m.List = ListMapping{Enabled: true, Columns: []string{"last_modified"}}
+		}, []string{"path /drafts/content/:id: list column last_modified is not a configured column"}},
+		{"media types", "/drafts/content/:id", func(m *Mapping) {
+			m.Consumes = []string{"application/json", "text/*"}
+			m.Produces = []string{"text/html; charset=utf-8", "application/json"}
+		}, nil},
+		{"invalid media types", "/drafts/content/:id", func(m *Mapping) {
+			m.Consumes = []string{"json"}
+			m.Produces = []string{"text/*"}
+		}, []string{
+			`path /drafts/content/:id: consumes "json" is not a valid media type`,
+			"path /drafts/content/:id: produces text/* is a media range, rather than the media type of documents",
+		}},
+		{"create", "/drafts/content/:id", func(m *Mapping) {
+			m.Create = CreateMapping{Enabled: true}
+		}, nil},
+		{"create with a configured id", "/drafts/:brand/content/:id", func(m *Mapping) {
+			m.Columns["brand"] = Column{Expr: ":brand"}
+			m.Create = CreateMapping{Enabled: true, ID: `concat(:brand, "-", coalesce($.id, uuid()))`}
+		}, nil},
+		{"create on a configured collection path", "/drafts/content/:id/annotations", func(m *Mapping) {
+			m.CollectionPath = "/drafts/annotations"
+			m.Create = CreateMapping{Enabled: true}
+		}, nil},
+		{"create without a path parameter", "/drafts/:brand/content/:id", func(m *Mapping) {
+			m.Columns["brand"] = Column{Expr: ":brand"}
+			m.CollectionPath = "/drafts/content"
+			m.Create = CreateMapping{Enabled: true, ID: "concat(:brand, uuid())"}
+		}, []string{
+			"path /drafts/:brand/content/:id: create is enabled, but the collection path /drafts/content has no :brand parameter for the path of created documents",
+			"path /drafts/:brand/content/:id: create.id refers to parameter :brand, which is not in the collection path or a declared query parameter",
+		}},
+		{"create with an invalid id", "/drafts/content/:id", func(m *Mapping) {
+			m.Create = CreateMapping{Enabled: true, ID: "$"}
+		}, []string{"path /drafts/content/:id: create.id cannot be the whole document ($)"}},
+		{"create with an unparseable id", "/drafts/content/:id", func(m *Mapping) {
+			m.Create = CreateMapping{Enabled: true, ID: "lower(:id"}
+		}, []string{`path /drafts/content/:id: create.id has an invalid expression "lower(:id": missing ) after arguments of lower() at position 10`}},
+		{"composite primary key", "/content/:uuid/annotations/:platform", func(m *Mapping) {
+			m.Columns["uuid"] = Column{Expr: ":uuid"}
+			m.Columns["platform"] = Column{Expr: ":platform"}
+			m.PrimaryKey = PrimaryKey{"uuid", "platform"}
+		}, nil},
+		{"composite primary key not mapped to the route", "/content/:uuid/annotations/:platform", func(m *Mapping) {
+			m.Columns["uuid"] = Column{Expr: ":uuid"}
+			m.Columns["platform"] = Column{Expr: "$.platform"}
+			m.Columns["brand"] = Column{Expr: ":query.brand"}
+			m.Query = QueryMapping{Allowed: []string{"brand"}}
+			m.PrimaryKey = PrimaryKey{"uuid", "platform", "brand", "uuid"}
+		}, []string{
+			`path /content/:uuid/annotations/:platform: primaryKey column platform is mapped to "$.platform", rather than to a parameter of the route`,
+			`path /content/:uuid/annotations/:platform: primaryKey column brand is mapped to ":query.brand", rather than to a parameter of the route`,
+			"path /content/:uuid/annotations/:platform: primaryKey column uuid is listed more than once",
+		}},
+		{"create with a composite primary key", "/content/:uuid/annotations/:id", func(m *Mapping) {
+			m.Columns["uuid"] = Column{Expr: ":uuid"}
+			m.Columns["platform"] = Column{Expr: ":id"}
+			m.PrimaryKey = PrimaryKey{"uuid", "platform"}
+			m.Create = CreateMapping{Enabled: true}
+		}, nil},
+		{"create without an id in the composite primary key", "/content/:uuid/annotations/:platform", func(m *Mapping) {
+			m.Columns["uuid"] = Column{Expr: ":uuid"}
+			m.Columns["platform"] = Column{Expr: ":platform"}
+			m.PrimaryKey = PrimaryKey{"uuid", "platform"}
+			m.CollectionPath = "/content/:uuid/annotations"
+			m.Create = CreateMapping{Enabled: true}
+		}, []string{
+			"path /content/:uuid/annotations/:platform: create is enabled, but :id, which is generated, is not a parameter of the primary key",
+			"path /content/:uuid/annotations/:platform: create is enabled, but the collection path /content/:uuid/annotations has no :platform parameter for the path of created documents",
+		}},
+		{"collection endpoints on a configured collection path", "/content/:uuid/annotations/:platform", func(m *Mapping) {
+			m.Columns["uuid"] = Column{Expr: ":uuid"}
+			m.Columns["platform"] = Column{Expr: ":platform"}
+			m.PrimaryKey = PrimaryKey{"uuid", "platform"}
+			m.CollectionPath = "/annotations"
+			m.List = ListMapping{Enabled: true}
+			m.AllowBulkRead = true
+			m.BulkWrite = BulkWriteMapping{Enabled: true}
+		}, nil},
+		{"collection endpoints on a collection path with parameters", "/content/:uuid/annotations/:platform", func(m *Mapping) {
+			m.Columns["uuid"] = Column{Expr: ":uuid"}
+			m.Columns["platform"] = Column{Expr: ":platform"}
+			m.PrimaryKey = PrimaryKey{"uuid", "platform"}
+			m.List = ListMapping{Enabled: true}
+			m.AllowBulkRead = true
+			m.BulkWrite = BulkWriteMapping{Enabled: true}
+		}, []string{
+			"path /content/:uuid/annotations/:platform: list is enabled, but the collection path /content/:uuid/annotations has parameters (:uuid), which would not restrict the documents it applies to",
+			"path /content/:uuid/annotations/:platform: allowBulkRead is enabled, but the collection path /content/:uuid/annotations has parameters (:uuid), which would not restrict the documents it applies to",
+			"path /content/:uuid/annotations/:platform: bulkWrite is enabled, but the collection path /content/:uuid/annotations has parameters (:uuid), which would not restrict the documents it applies to",
+		}},
+		{"path without a leading /", "things", func(m *Mapping) {}, []string{
+			"path things: path must start with /",
+			"path things: route has no :id parameter for the document key",
+			"path things: column uuid refers to parameter :id, which is not in the route or a declared query parameter",
+		}},
+		{"path without a leading / and collection endpoints", "things/:id", func(m *Mapping) {
+			m.List = ListMapping{Enabled: true}
+			m.Create = CreateMapping{Enabled: true}
+		}, []string{"path things/:id: path must start with /"}},
+		{"collection endpoints on a path at the root", "/:id", func(m *Mapping) {
+			m.List = ListMapping{Enabled: true}
+			m.Create = CreateMapping{Enabled: true}
+		}, []string{
+			"path /:id: list is enabled, but the path has no parent for the collection endpoints, and no collectionPath is configured",
+			"path /:id: create is enabled, but the path has no parent for the collection endpoints, and no collectionPath is configured",
+		}},
+		{"collection endpoints on a configured collection path for a path at the root", "/:id", func(m *Mapping) {
+			m.CollectionPath = "/things"
+			m.List = ListMapping{Enabled: true}
+			m.Create = CreateMapping{Enabled: true}
+		}, nil},
+		{"collection path without a leading /", "/drafts/content/:id", func(m *Mapping) {
+			m.CollectionPath = "drafts"
+		}, []string{"path /drafts/content/:id: collectionPath must start with /"}},
+		{"several problems", "/drafts/content/:id", func(m *Mapping) {
+			m.Table = ""
+			m.BulkWrite.ChunkSize = -1
+			m.BulkWrite.Timeout = -time.Second
+		}, []string{
+			"path /drafts/content/:id: table is not configured",
+			"path /drafts/content/:id: bulkWrite.chunkSize must not be negative",
+			"path /drafts/content/:id: bulkWrite.timeout must not be negative",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := validMapping()
+			test.modify(&m)
+			cfg := &Config{Paths: map[string]Mapping{test.path: m}}
+
+			err := cfg.Validate()
+			if test.problems == nil {
+				assert.NoError(t, err)
+				return
+			}
+			require.IsType(t, &ValidationError{}, err)
+			assert.Equal(t, test.problems, err.(*ValidationError).Problems)
+		})
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Problems: []string{"first problem", "second problem"}}
+	assert.EqualError(t, err, "2 problem(s) found in r/w configuration: first problem; second problem")
+}
+
+func TestValidateRoutes(t *testing.T) {
+	shared := validMapping()
+	shared.CollectionPath = "/shared"
+	shared.List = ListMapping{Enabled: true}
+	shared.AllowBulkRead = true
+	shared.Create = CreateMapping{Enabled: true}
+	shared.BulkWrite = BulkWriteMapping{Enabled: true}
+
+	cfg := &Config{Paths: map[string]Mapping{
+		"/drafts/content/:id":      shared,
+		"/published/content/:id":   shared,
+		"/drafts/annotations/:id":  validMapping(),
+		"/drafts/annotations/:key": validMapping(),
+	}}
+
+	err := cfg.Validate()
+	require.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, []string{
+		"path /drafts/annotations/:key: route has no :id parameter for the document key",
+		"path /drafts/annotations/:key: column uuid refers to parameter :id, which is not in the route or a declared query parameter",
+		"route GET /drafts/annotations/:id is created more than once, by paths /drafts/annotations/:id, /drafts/annotations/:key",
+		"route PUT /drafts/annotations/:id is created more than once, by paths /drafts/annotations/:id, /drafts/annotations/:key",
+		"route PATCH /drafts/annotations/:id is created more than once, by paths /drafts/annotations/:id, /drafts/annotations/:key",
+		"route GET /shared is created more than once, by paths /drafts/content/:id, /published/content/:id",
+		"route POST /shared/__bulk-read is created more than once, by paths /drafts/content/:id, /published/content/:id",
+		"route POST /shared is created more than once, by paths /drafts/content/:id, /published/content/:id",
+		"route POST /shared/__bulk-write is created more than once, by paths /drafts/content/:id, /published/content/:id",
+	}, err.(*ValidationError).Problems)
+}
+
+func TestValidateRoutesOfOnePath(t *testing.T) {
+	m := validMapping()
+	m.CollectionPath = "/drafts/content/:id"
+	m.List = ListMapping{Enabled: true}
+	cfg := &Config{Paths: map[string]Mapping{"/drafts/content/:id": m}}
+
+	err := cfg.Validate()
+	require.IsType(t, &ValidationError{}, err)
+	assert.Contains(t, err.(*ValidationError).Problems, "route GET /drafts/content/:id is created more than once, by paths /drafts/content/:id, /drafts/content/:id")
+}
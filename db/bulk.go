@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// BulkWriteItem is one of the documents written by a bulk write
+type BulkWriteItem struct {
+	Key                  string
+	Document             Document
+	Params               map[string]string
+	PreviousDocumentHash string
+	// Err, if set, is the reason the item cannot be written. The item fails like a failed write, without being attempted.
+	Err error
+}
+
+// BulkWriteResult is the outcome of writing a BulkWriteItem. Err is set if the item was not written,
+// either because it failed or because its transaction was rolled back.
+type BulkWriteResult struct {
+	Key    string
+	Status WriteStatus
+	Hash   string
+	Err    error
+}
+
+// RolledBackError is the result of an item that was written successfully, but whose transaction was rolled back
+// because another item in the same transaction failed
+type RolledBackError struct {
+	FailedKey string
+}
+
+func (e *RolledBackError) Error() string {
+	return fmt.Sprintf("transaction rolled back because the write of document %s failed", e.FailedKey)
+}
+
+// ErrNotAttempted is the result of an item that was not written because an earlier item in the same transaction failed
+var ErrNotAttempted = errors.New("document was not written because an earlier document in the same transaction failed")
+
+// BulkWrite writes the items in transactions of up to the configured chunk size for the table, or in a single transaction
+// if no chunk size is configured. A failure rolls back the whole transaction, but transactions of other chunks are unaffected.
+// If a transaction cannot be started, the documents of earlier chunks are still written, and every other document fails with the error.
+func (service *AuroraRWService) BulkWrite(ctx context.Context, tableName string, items []BulkWriteItem) ([]BulkWriteResult, error) {
+	ctx = context.WithValue(ctx, contextTable, tableName)
+
+	bulkLog := buildLogEntryFromContext(ctx).WithField("count", len(items))
+	bulkLog.Info("Writing documents to database")
+
+	chunkSize := service.mappedTable(tableName).bulkWriteChunkSize
+	if chunkSize <= 0 {
+		chunkSize = len(items)
+	}
+
+	results := make([]BulkWriteResult, 0, len(items))
+	for start := 0; start < len(items); start += chunkSize {
+		end := start + chunkSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		chunkResults, err := service.bulkWriteChunk(ctx, tableName, items[start:end])
+		if err != nil {
+			// earlier chunks are committed, so the outcome of every document is still reported
+			bulkLog.WithError(err).Error("unable to write documents to database")
+			for _, item := range items[start:] {
+				results = append(results, BulkWriteResult{Key: item.Key, Err: err})
+			}
+			return results, nil
+		}
+		results = append(results, chunkResults...)
+	}
+
+	return results, nil
+}
+
+func (service *AuroraRWService) bulkWriteChunk(ctx context.Context, tableName string, items []BulkWriteItem) ([]BulkWriteResult, error) {
+	tx, err := service.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BulkWriteResult, len(items))
+	failed := -1
+	for i, item := range items {
+		results[i].Key = item.Key
+		if failed >= 0 {
+			results[i].Err = ErrNotAttempted
+			continue
+		}
+
+		if item.Err != nil {
+			results[i].Err = item.Err
+		} else {
+			results[i].Status, results[i].Hash, results[i].Err = service.write(ctx, tx, tableName, item.Key, item.Document, item.Params, item.PreviousDocumentHash)
+		}
+		if results[i].Err != nil {
+			failed = i
+		}
+	}
+
+	if failed >= 0 {
+		tx.Rollback()
+		for i := 0; i < failed; i++ {
+			results[i].Err = &RolledBackError{FailedKey: items[failed].Key}
+		}
+		return results, nil
+	}
+
+	if err = tx.Commit(); err != nil {
+		for i := range results {
+			results[i].Err = err
+		}
+	}
+	return results, nil
+}
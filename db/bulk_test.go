@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	tid "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceRWTestSuite) newBulkWriteItem(key string, testTID string, previousDocHash string) BulkWriteItem {
+	testDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, key)))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	return BulkWriteItem{Key: key, Document: testDoc, Params: map[string]string{"id": key}, PreviousDocumentHash: previousDocHash}
+}
+
+func (s *ServiceRWTestSuite) TestBulkWrite() {
+	testTID := "tid_testbulkwrite"
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	existingKey := uuid.NewV4().String()
+	_, _, err := s.service.Write(testCtx, testTable, existingKey, s.newBulkWriteItem(existingKey, testTID, "").Document, map[string]string{"id": existingKey}, "")
+	require.NoError(s.T(), err)
+
+	newKey := uuid.NewV4().String()
+	items := []BulkWriteItem{
+		s.newBulkWriteItem(newKey, testTID, ""),
+		s.newBulkWriteItem(existingKey, testTID, ""),
+	}
+	items[1].Document.Body = []byte(fmt.Sprintf(testDocTemplate, "updated"))
+
+	results, err := s.service.BulkWrite(testCtx, testTable, items)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), results, 2)
+
+	assert.NoError(s.T(), results[0].Err)
+	assert.Equal(s.T(), Created, results[0].Status)
+	assert.NoError(s.T(), results[1].Err)
+	assert.Equal(s.T(), Updated, results[1].Status)
+
+	s.assertExpectedDataInDB(newKey, testKeyColumn, testTable, map[string]string{testDocColumn: fmt.Sprintf(testDocTemplate, newKey), hashColumn: results[0].Hash})
+	s.assertExpectedDataInDB(existingKey, testKeyColumn, testTable, map[string]string{testDocColumn: fmt.Sprintf(testDocTemplate, "updated"), hashColumn: results[1].Hash})
+}
+
+func (s *ServiceRWTestSuite) TestBulkWriteRollback() {
+	testTID := "tid_testbulkwrite"
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	existingKey := uuid.NewV4().String()
+	_, existingHash, err := s.service.Write(testCtx, testTable, existingKey, s.newBulkWriteItem(existingKey, testTID, "").Document, map[string]string{"id": existingKey}, "")
+	require.NoError(s.T(), err)
+
+	newKey := uuid.NewV4().String()
+	items := []BulkWriteItem{
+		s.newBulkWriteItem(newKey, testTID, ""),
+		s.newBulkWriteItem(existingKey, testTID, NoDocumentHash),
+		s.newBulkWriteItem(uuid.NewV4().String(), testTID, ""),
+	}
+
+	results, err := s.service.BulkWrite(testCtx, testTable, items)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), results, 3)
+
+	assert.IsType(s.T(), &RolledBackError{}, results[0].Err)
+	require.IsType(s.T(), &ConflictError{}, results[1].Err)
+	assert.Equal(s.T(), existingHash, results[1].Err.(*ConflictError).CurrentHash)
+	assert.Equal(s.T(), ErrNotAttempted, results[2].Err)
+
+	_, err = s.service.Read(testCtx, testTable, newKey)
+	assert.Error(s.T(), err, "rolled back document should not be found")
+}
+
+func (s *ServiceRWTestSuite) TestBulkWriteInvalidItem() {
+	testTID := "tid_testbulkwrite"
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	newKey := uuid.NewV4().String()
+	invalid := errors.New("document is not valid")
+	items := []BulkWriteItem{
+		s.newBulkWriteItem(newKey, testTID, ""),
+		s.newBulkWriteItem(uuid.NewV4().String(), testTID, ""),
+	}
+	items[1].Err = invalid
+
+	results, err := s.service.BulkWrite(testCtx, testTable, items)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), results, 2)
+
+	assert.IsType(s.T(), &RolledBackError{}, results[0].Err)
+	assert.Equal(s.T(), invalid, results[1].Err)
+
+	_, err = s.service.Read(testCtx, testTable, newKey)
+	assert.Error(s.T(), err, "rolled back document should not be found")
+}
+
+func TestBulkWriteUnableToStartTransaction(t *testing.T) {
+	conn, err := sql.Open("mysql", "user:pass@/test")
+	require.NoError(t, err)
+	conn.Close()
+
+	service, err := newService(conn, &config.Config{Paths: map[string]config.Mapping{
+		"/things/:id": {
+			Table:      "things",
+			Columns:    map[string]config.Column{"uuid": {Expr: ":id"}, "body": {Expr: "$"}},
+			PrimaryKey: config.PrimaryKey{"uuid"},
+			BulkWrite:  config.BulkWriteMapping{Enabled: true, ChunkSize: 1},
+		},
+	}})
+	require.NoError(t, err)
+
+	items := []BulkWriteItem{
+		{Key: "1", Document: NewDocument([]byte(`{}`)), Params: map[string]string{"id": "1"}},
+		{Key: "2", Document: NewDocument([]byte(`{}`)), Params: map[string]string{"id": "2"}},
+	}
+	results, err := service.BulkWrite(context.Background(), "things", items)
+	require.NoError(t, err, "the outcome of every document is reported instead")
+	require.Len(t, results, 2)
+	for i, result := range results {
+		assert.Equal(t, items[i].Key, result.Key)
+		assert.EqualError(t, result.Err, "sql: database is closed")
+	}
+}
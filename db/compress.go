@@ -0,0 +1,47 @@
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+)
+
+// compressedBodyPrefix starts the base64 encoding of every gzip stream, whose first bytes are fixed
+const compressedBodyPrefix = "H4sI"
+
+// compressBody encodes a document body for a table storing compressed bodies, as base64 so that it can be stored in a text column
+func compressBody(body []byte) (string, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(body); err != nil {
+		return "", err
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decompressBody decodes a stored document body if it was compressed. A body stored as it is, e.g. before its table stored compressed bodies,
+// is returned unchanged. As a document that is not JSON may look like a compressed body, a body is only taken to be compressed if the
+// stored hash, which is that of the uncompressed document, matches the decompressed body.
+func decompressBody(stored []byte, storedHash string) []byte {
+	if !bytes.HasPrefix(stored, []byte(compressedBodyPrefix)) {
+		return stored
+	}
+	compressed, err := base64.StdEncoding.DecodeString(string(stored))
+	if err != nil {
+		return stored
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return stored
+	}
+	defer gz.Close()
+	body, err := ioutil.ReadAll(gz)
+	if err != nil || hash(body) != storedHash {
+		return stored
+	}
+	return body
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressBody(t *testing.T) {
+	body := []byte(`{"title":"a draft","body":"<p>a long body</p>"}`)
+
+	stored, err := compressBody(body)
+	require.NoError(t, err)
+	assert.Regexp(t, "^"+compressedBodyPrefix, stored)
+
+	assert.Equal(t, body, decompressBody([]byte(stored), hash(body)))
+}
+
+func TestDecompressUncompressedBody(t *testing.T) {
+	for _, body := range []string{`{"title":"a draft"}`, "H4sI is not base64 encoded gzip", "H4sIaGVsbG8="} {
+		assert.Equal(t, body, string(decompressBody([]byte(body), hash([]byte(body)))))
+	}
+}
+
+func TestDecompressBodyThatLooksCompressed(t *testing.T) {
+	// a document that is not JSON, stored as it is, may be the compressed encoding of another document
+	body, err := compressBody([]byte("some text"))
+	require.NoError(t, err)
+
+	assert.Equal(t, body, string(decompressBody([]byte(body), hash([]byte(body)))))
+}
+
+func TestBuildColumnValuesCompressedBody(t *testing.T) {
+	tables, _, err := mapTables(&config.Config{Paths: map[string]config.Mapping{
+		"/things/:id": {
+			Table: "things",
+			Columns: map[string]config.Column{
+				"uuid":    {Expr: ":id"},
+				"version": {Expr: "$.version", Type: config.ColumnTypeInt},
+				"body":    {Expr: "$"},
+			},
+			PrimaryKey:   config.PrimaryKey{"uuid"},
+			CompressBody: true,
+		},
+	}})
+	require.NoError(t, err)
+
+	body := []byte(`{"version":7}`)
+	doc := NewDocument(body)
+	doc.Hash = hash(body)
+	values, err := buildColumnValues(context.Background(), tables["things"], "1234", doc, map[string]string{"id": "1234"})
+	require.NoError(t, err)
+	require.Len(t, values, 4)
+
+	assert.Equal(t, hash(body), values[0], "the hash is that of the uncompressed body")
+	assert.Equal(t, body, decompressBody([]byte(values[1].(string)), values[0].(string)))
+	assert.Equal(t, int64(7), values[3], "expressions are evaluated against the uncompressed body")
+}
@@ -0,0 +1,40 @@
+package db
+
+import "github.com/Financial-Times/generic-rw-aurora/config"
+
+// Statements are the SQL statements used to read and write the documents of a table
+type Statements struct {
+	// Read selects a document and its response metadata
+	Read string
+	// Insert creates a document
+	Insert string
+	// Update updates a stored document, on condition of its previous hash if the table has conflict detection
+	Update string
+	// Upsert creates a document or overwrites the stored one
+	Upsert string
+}
+
+// DescribeStatements returns the statements used for each table mapped by the configuration, by table name,
+// without connecting to the database
+func DescribeStatements(rwConfig *config.Config) (map[string]Statements, error) {
+	service, err := newService(nil, rwConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := make(map[string]Statements)
+	for name, t := range service.mappedTables() {
+		docQuery, err := service.newDocumentQuery(name)
+		if err != nil {
+			return nil, err
+		}
+
+		statements[name] = Statements{
+			Read:   t.readQuery(docQuery),
+			Insert: t.insertStatement(),
+			Update: t.updateStatement(t.hasConflictDetection),
+			Upsert: t.upsertStatement(),
+		}
+	}
+	return statements, nil
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeStatements(t *testing.T) {
+	cfg, err := config.ReadConfig("../config.yml")
+	require.NoError(t, err)
+
+	statements, err := DescribeStatements(cfg)
+	require.NoError(t, err)
+	require.Len(t, statements, 3)
+
+	assert.Equal(t, Statements{
+		Read:   "SELECT body,hash FROM draft_annotations WHERE uuid = ?",
+		Insert: "INSERT INTO draft_annotations (hash,body,last_modified,publish_ref,uuid) VALUES (?,?,?,?,?)",
+		Update: "UPDATE draft_annotations SET hash=?,body=?,last_modified=?,publish_ref=?,uuid=? WHERE uuid = ? AND hash = ?",
+		Upsert: "INSERT INTO draft_annotations (hash,body,last_modified,publish_ref,uuid) VALUES (?,?,?,?,?) ON DUPLICATE KEY UPDATE hash=?,body=?,last_modified=?,publish_ref=?,uuid=?",
+	}, statements[testTableWithConflictDetection])
+
+	assert.Equal(t, "UPDATE published_annotations SET hash=?,body=?,last_modified=?,publish_ref=?,uuid=? WHERE uuid = ?", statements[testTable].Update)
+	assert.Equal(t, "SELECT body,hash,content_type,last_modified,draft_ref,origin_system FROM draft_content WHERE uuid = ?", statements[testTableWithMetadata].Read)
+}
+
+func TestDescribeStatementsWithoutDocumentColumn(t *testing.T) {
+	cfg := &config.Config{Paths: map[string]config.Mapping{
+		"/things/:id": {Table: "things", Columns: map[string]config.Column{"uuid": {Expr: ":id"}}, PrimaryKey: config.PrimaryKey{"uuid"}},
+	}}
+
+	_, err := DescribeStatements(cfg)
+	assert.EqualError(t, err, "document column is not configured for table things")
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictError is returned when a write or delete is rejected because the document hash
+// stored in the database does not match the hash expected by the client.
+type ConflictError struct {
+	CurrentHash string
+}
+
+func (e *ConflictError) Error() string {
+	if e.CurrentHash == "" {
+		return "document hash conflict: document does not exist"
+	}
+	return fmt.Sprintf("document hash conflict: current document hash is %s", e.CurrentHash)
+}
+
+// PatchError is returned when a patch cannot be applied to the stored document
+type PatchError struct {
+	Err error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("unable to apply patch to document: %v", e.Err)
+}
+
+// ValueError is returned when the value of a column expression cannot be converted to the type of the column
+type ValueError struct {
+	Column string
+	Type   string
+	Err    error
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("invalid value for %s column %s: %v", e.Type, e.Column, e.Err)
+}
+
+// MissingValuesError is returned when the values of required columns are missing or empty
+type MissingValuesError struct {
+	// Columns lists each column with its expression, e.g. origin_system (@.x-origin-system-id)
+	Columns []string
+}
+
+func (e *MissingValuesError) Error() string {
+	return fmt.Sprintf("missing required value(s) for column(s): %s", strings.Join(e.Columns, ", "))
+}
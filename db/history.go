@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Financial-Times/generic-rw-aurora/expression"
+)
+
+const historyTableSuffix = "_history"
+
+// historyIdColumn orders the rows of a history table, oldest first
+const historyIdColumn = "history_id"
+
+// Version describes a superseded document kept in the history of a table
+type Version struct {
+	Hash     string
+	Metadata DocMetadata
+}
+
+func (t *table) historyTable() string {
+	return t.name + historyTableSuffix
+}
+
+// archiveDocument copies the document currently stored for the key, if any, to the history table
+func (service *AuroraRWService) archiveDocument(ctx context.Context, exec sqlExecutor, t table, key string) error {
+	archiveLog := buildLogEntryFromContext(ctx)
+
+	cols := strings.Join(t.storedColumns(), ",")
+	archiveStmt := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE %s", t.historyTable(), cols, cols, t.name, t.keyCondition())
+	archived, err := service.executeStatement(exec, archiveStmt, t.keyValues(key))
+	if err != nil {
+		archiveLog.WithError(err).Error("unable to archive document to history")
+		return err
+	}
+
+	if archived == 0 || t.historyLimit <= 0 {
+		return nil
+	}
+	return service.pruneHistory(ctx, exec, t, key)
+}
+
+// pruneHistory removes all but the most recent versions of the document stored for the key, up to the history limit of the table
+func (service *AuroraRWService) pruneHistory(ctx context.Context, exec sqlExecutor, t table, key string) error {
+	pruneLog := buildLogEntryFromContext(ctx)
+
+	// find the most recent version beyond the limit; it and any older versions are removed
+	var historyId int64
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s DESC LIMIT 1 OFFSET ?", historyIdColumn, t.historyTable(), t.keyCondition(), historyIdColumn)
+	err := exec.QueryRow(query, append(t.keyValues(key), t.historyLimit)...).Scan(&historyId)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		pruneLog.WithError(err).Error("unable to read history from database")
+		return err
+	}
+
+	pruneStmt := fmt.Sprintf("DELETE FROM %s WHERE %s AND %s <= ?", t.historyTable(), t.keyCondition(), historyIdColumn)
+	pruned, err := service.executeStatement(exec, pruneStmt, append(t.keyValues(key), historyId))
+	if err != nil {
+		pruneLog.WithError(err).Error("unable to prune history")
+		return err
+	}
+	pruneLog.WithField("count", pruned).Info("Pruned document history")
+	return nil
+}
+
+// History returns the superseded versions of the document stored for the key, most recent first.
+// The metadata of each version holds the values of its configured columns, except the document itself.
+func (service *AuroraRWService) History(ctx context.Context, tableName string, key string) ([]Version, error) {
+	ctx = context.WithValue(ctx, contextTable, tableName)
+	ctx = context.WithValue(ctx, contextDocumentKey, key)
+
+	historyLog := buildLogEntryFromContext(ctx)
+	historyLog.Info("Reading document history from database")
+
+	table := service.mappedTable(tableName)
+	docQuery, err := service.newDocumentQuery(tableName)
+	if err != nil {
+		historyLog.Error("document column is not configured")
+		return nil, err
+	}
+
+	var cols []string
+	for _, col := range table.storedColumns() {
+		if col != docQuery.columns[0] {
+			cols = append(cols, col)
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s DESC", strings.Join(cols, ","), table.historyTable(), table.keyCondition(), historyIdColumn)
+	rows, err := service.conn.Query(query, table.keyValues(key)...)
+	if err != nil {
+		historyLog.WithError(err).Error("unable to read history from database")
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []Version{}
+	for rows.Next() {
+		vals := make([]sql.NullString, len(cols))
+		if err = rows.Scan(scanDest(vals)...); err != nil {
+			historyLog.WithError(err).Error("unable to read history from database")
+			return nil, err
+		}
+
+		version := Version{Hash: vals[0].String, Metadata: table.metadata(cols[1:], vals[1:])}
+		versions = append(versions, version)
+	}
+
+	if err = rows.Err(); err != nil {
+		historyLog.WithError(err).Error("unable to read history from database")
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// ReadVersion returns the superseded version of the document stored for the key with the given hash,
+// or sql.ErrNoRows if there is no such version in the history
+func (service *AuroraRWService) ReadVersion(ctx context.Context, tableName string, key string, hash string) (Document, error) {
+	ctx = context.WithValue(ctx, contextTable, tableName)
+	ctx = context.WithValue(ctx, contextDocumentKey, key)
+
+	readLog := buildLogEntryFromContext(ctx).WithField("hash", hash)
+	readLog.Info("Reading document version from database")
+
+	table := service.mappedTable(tableName)
+	docQuery, err := service.newDocumentQuery(tableName)
+	if err != nil {
+		readLog.Error("document column is not configured")
+		return Document{}, err
+	}
+
+	// the same version may have been archived more than once, so the most recent copy is returned
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s AND %s = ? ORDER BY %s DESC LIMIT 1", strings.Join(docQuery.columns, ","), table.historyTable(), table.keyCondition(), hashColumn, historyIdColumn)
+	rows, err := service.conn.Query(query, append(table.keyValues(key), hash)...)
+	if err != nil {
+		readLog.WithError(err).Error("unable to read history from database")
+		return Document{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			readLog.WithError(err).Error("unable to read history from database")
+			return Document{}, err
+		}
+		return Document{}, sql.ErrNoRows
+	}
+
+	doc, err := docQuery.scan(rows)
+	if err != nil {
+		readLog.WithError(err).Error("unable to read history from database")
+		return Document{}, err
+	}
+
+	return doc, nil
+}
+
+// Restore writes the superseded version of the document stored for the key with the given hash, as a new version with the metadata of doc.
+// Metadata values that doc does not carry are taken from the columns of the restored version.
+// It returns sql.ErrNoRows if there is no such version in the history.
+func (service *AuroraRWService) Restore(ctx context.Context, tableName string, key string, hash string, doc Document, params map[string]string, previousDocHash string) (WriteStatus, string, error) {
+	ctx = context.WithValue(ctx, contextTable, tableName)
+	ctx = context.WithValue(ctx, contextDocumentKey, key)
+
+	restoreLog := buildLogEntryFromContext(ctx).WithField("hash", hash)
+	restoreLog.Info("Restoring document version in database")
+
+	table := service.mappedTable(tableName)
+
+	var status WriteStatus
+	var newHash string
+	err := service.inTransaction(ctx, func(tx *sql.Tx) error {
+		cols := table.storedColumns()
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s AND %s = ? ORDER BY %s DESC LIMIT 1", strings.Join(cols, ","), table.historyTable(), table.keyCondition(), hashColumn, historyIdColumn)
+
+		vals := make([]sql.NullString, len(cols))
+		if err := tx.QueryRow(query, append(table.keyValues(key), hash)...).Scan(scanDest(vals)...); err != nil {
+			if err != sql.ErrNoRows {
+				restoreLog.WithError(err).Error("unable to read history from database")
+			}
+			return err
+		}
+
+		for i, col := range cols {
+			expr := table.expressions[col]
+			if expr == nil || !vals[i].Valid {
+				continue
+			}
+			if expression.IsDocument(expr) {
+				doc.Body = decompressBody([]byte(vals[i].String), hash)
+			} else if name, ok := expression.MetadataName(expr); ok {
+				if _, found := doc.Metadata[name]; !found {
+					// stored values are formatted as they were in the request, to be converted again when written
+					doc.Metadata.Set(name, table.types[col].fromDatabase(vals[i].String))
+				}
+			}
+		}
+
+		var err error
+		status, newHash, err = service.write(ctx, tx, tableName, key, doc, params, previousDocHash)
+		return err
+	})
+	return status, newHash, err
+}
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	tid "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (s *ServiceRWTestSuite) newHistoryService() *AuroraRWService {
+	cfg, err := config.ReadConfig("../config.yml")
+	require.NoError(s.T(), err)
+	for path, mapping := range cfg.Paths {
+		mapping.History = true
+		cfg.Paths[path] = mapping
+	}
+	service, err := NewService(s.dbConn, false, cfg)
+	require.NoError(s.T(), err)
+	return service
+}
+
+func (s *ServiceRWTestSuite) newHistoryDocument(body string, testTID string) Document {
+	testDoc := NewDocument([]byte(body))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+	return testDoc
+}
+
+func (s *ServiceRWTestSuite) TestHistory() {
+	service := s.newHistoryService()
+
+	testKey := uuid.NewV4().String()
+	params := map[string]string{"id": testKey}
+
+	var hashes []string
+	for i := 0; i < 3; i++ {
+		testTID := fmt.Sprintf("tid_testhistory_%d", i)
+		testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+		_, docHash, err := service.Write(testCtx, testTable, testKey, s.newHistoryDocument(fmt.Sprintf(testDocTemplate, testTID), testTID), params, "")
+		require.NoError(s.T(), err)
+		hashes = append(hashes, docHash)
+	}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), "tid_testhistory")
+
+	versions, err := service.History(testCtx, testTable, testKey)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), versions, 2)
+	assert.Equal(s.T(), hashes[1], versions[0].Hash)
+	assert.Equal(s.T(), "tid_testhistory_1", versions[0].Metadata[publishRefColumn])
+	assert.Equal(s.T(), hashes[0], versions[1].Hash)
+	assert.NotContains(s.T(), versions[1].Metadata, testDocColumn)
+
+	version, err := service.ReadVersion(testCtx, testTable, testKey, hashes[0])
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), fmt.Sprintf(testDocTemplate, "tid_testhistory_0"), string(version.Body))
+	assert.Equal(s.T(), hashes[0], version.Hash)
+
+	_, err = service.ReadVersion(testCtx, testTable, testKey, hashes[2])
+	assert.EqualError(s.T(), err, sql.ErrNoRows.Error())
+}
+
+func (s *ServiceRWTestSuite) TestHistoryNotArchivedOnUnchangedWrite() {
+	service := s.newHistoryService()
+
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testhistory_unchanged"
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+	params := map[string]string{"id": testKey}
+
+	for i := 0; i < 3; i++ {
+		_, _, err := service.Write(testCtx, testTable, testKey, s.newHistoryDocument(fmt.Sprintf(testDocTemplate, testTID), testTID), params, "")
+		require.NoError(s.T(), err)
+	}
+
+	versions, err := service.History(testCtx, testTable, testKey)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), versions, "a document written again as it is stored is not a new version")
+}
+
+func (s *ServiceRWTestSuite) TestHistoryOfDeletedDocument() {
+	service := s.newHistoryService()
+
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testhistory"
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, docHash, err := service.Write(testCtx, testTable, testKey, s.newHistoryDocument(fmt.Sprintf(testDocTemplate, testTID), testTID), map[string]string{"id": testKey}, "")
+	require.NoError(s.T(), err)
+
+	err = service.Delete(testCtx, testTable, testKey, "")
+	require.NoError(s.T(), err)
+
+	versions, err := service.History(testCtx, testTable, testKey)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), versions, 1)
+	assert.Equal(s.T(), docHash, versions[0].Hash)
+}
+
+func (s *ServiceRWTestSuite) TestHistoryNotArchivedOnRejectedConflict() {
+	cfg, err := config.ReadConfig("../config.yml")
+	require.NoError(s.T(), err)
+	for path, mapping := range cfg.Paths {
+		mapping.History = true
+		mapping.ConflictPolicy = config.ConflictPolicyReject
+		cfg.Paths[path] = mapping
+	}
+	service, err := NewService(s.dbConn, false, cfg)
+	require.NoError(s.T(), err)
+
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testhistory"
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+	params := map[string]string{"id": testKey}
+
+	_, _, err = service.Write(testCtx, testTableWithConflictDetection, testKey, s.newHistoryDocument(`{"foo":"bar"}`, testTID), params, "")
+	require.NoError(s.T(), err)
+
+	aVeryOldHash := "01234567890123456789012345678901234567890123456789012345"
+	_, _, err = service.Write(testCtx, testTableWithConflictDetection, testKey, s.newHistoryDocument(`{"foo":"qux"}`, testTID), params, aVeryOldHash)
+	require.IsType(s.T(), &ConflictError{}, err)
+
+	versions, err := service.History(testCtx, testTableWithConflictDetection, testKey)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), versions)
+}
+
+func (s *ServiceRWTestSuite) TestHistoryLimit() {
+	cfg, err := config.ReadConfig("../config.yml")
+	require.NoError(s.T(), err)
+	for path, mapping := range cfg.Paths {
+		mapping.History = true
+		mapping.HistoryLimit = 2
+		cfg.Paths[path] = mapping
+	}
+	service, err := NewService(s.dbConn, false, cfg)
+	require.NoError(s.T(), err)
+
+	testKey := uuid.NewV4().String()
+	params := map[string]string{"id": testKey}
+
+	var hashes []string
+	for i := 0; i < 5; i++ {
+		testTID := fmt.Sprintf("tid_testhistorylimit_%d", i)
+		testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+		_, docHash, err := service.Write(testCtx, testTable, testKey, s.newHistoryDocument(fmt.Sprintf(testDocTemplate, testTID), testTID), params, "")
+		require.NoError(s.T(), err)
+		hashes = append(hashes, docHash)
+	}
+
+	versions, err := service.History(context.Background(), testTable, testKey)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), versions, 2)
+	assert.Equal(s.T(), hashes[3], versions[0].Hash)
+	assert.Equal(s.T(), hashes[2], versions[1].Hash)
+}
+
+func (s *ServiceRWTestSuite) TestRestore() {
+	service := s.newHistoryService()
+
+	testKey := uuid.NewV4().String()
+	params := map[string]string{"id": testKey}
+
+	testDocBody := `{"foo":"bar"}`
+	testCtx := tid.TransactionAwareContext(context.Background(), "tid_testrestore_1")
+	testDoc := s.newHistoryDocument(testDocBody, "tid_testrestore_1")
+	testDoc.Metadata.Set("x-origin-system-id", "restore-system")
+	testDoc.Metadata.Set("content-type", "application/json")
+	_, restoredHash, err := service.Write(testCtx, testTableWithMetadata, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	testCtx = tid.TransactionAwareContext(context.Background(), "tid_testrestore_2")
+	badDoc := s.newHistoryDocument("not json", "tid_testrestore_2")
+	badDoc.Metadata.Set("x-origin-system-id", "bad-system")
+	badDoc.Metadata.Set("content-type", "text/plain")
+	_, badHash, err := service.Write(testCtx, testTableWithMetadata, testKey, badDoc, params, "")
+	require.NoError(s.T(), err)
+
+	testCtx = tid.TransactionAwareContext(context.Background(), "tid_testrestore_3")
+	restoreDoc := s.newHistoryDocument("", "tid_testrestore_3")
+	restoreDoc.Body = nil
+
+	status, docHash, err := service.Restore(testCtx, testTableWithMetadata, testKey, restoredHash, restoreDoc, params, badHash)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), Updated, status)
+	assert.Equal(s.T(), restoredHash, docHash)
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTableWithMetadata, map[string]string{
+		testDocColumn:   testDocBody,
+		hashColumn:      restoredHash,
+		"draft_ref":     "tid_testrestore_3",
+		"origin_system": "restore-system",
+		"content_type":  "application/json",
+	})
+
+	versions, err := service.History(testCtx, testTableWithMetadata, testKey)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), versions, 2)
+	assert.Equal(s.T(), badHash, versions[0].Hash)
+}
+
+func (s *ServiceRWTestSuite) TestRestoreVersionNotFound() {
+	service := s.newHistoryService()
+
+	testKey := uuid.NewV4().String()
+	testCtx := tid.TransactionAwareContext(context.Background(), "tid_testrestore")
+
+	_, _, err := service.Restore(testCtx, testTable, testKey, "01234567890123456789012345678901234567890123456789012345", s.newHistoryDocument("", "tid_testrestore"), map[string]string{"id": testKey}, "")
+	assert.EqualError(s.T(), err, sql.ErrNoRows.Error())
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeySeparator joins the values of a composite primary key into the single key of a document
+const KeySeparator = "/"
+
+// JoinKey returns the key of a document from the values of its primary key columns
+func JoinKey(values []string) string {
+	return strings.Join(values, KeySeparator)
+}
+
+// SplitKey returns the values of n primary key columns from the key of a document.
+// The last value takes the remainder of the key, and missing values are empty.
+func SplitKey(key string, n int) []string {
+	if n <= 1 {
+		return []string{key}
+	}
+	values := strings.SplitN(key, KeySeparator, n)
+	for len(values) < n {
+		values = append(values, "")
+	}
+	return values
+}
+
+// keyCondition returns the condition selecting the row of a key, with one placeholder per primary key column
+func (t *table) keyCondition() string {
+	conditions := make([]string, len(t.primaryKey))
+	for i, col := range t.primaryKey {
+		conditions[i] = fmt.Sprintf("%s = ?", col)
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// keyValues returns the bindings of the key condition for a key
+func (t *table) keyValues(key string) []interface{} {
+	values := SplitKey(key, len(t.primaryKey))
+	bindings := make([]interface{}, len(values))
+	for i, value := range values {
+		bindings[i] = value
+	}
+	return bindings
+}
+
+// keyTuple returns the primary key columns as an SQL row constructor, or the column itself for a single column key
+func (t *table) keyTuple() string {
+	if len(t.primaryKey) == 1 {
+		return t.primaryKey[0]
+	}
+	return "(" + strings.Join(t.primaryKey, ",") + ")"
+}
+
+// keyPlaceholders returns the placeholders matching keyTuple
+func (t *table) keyPlaceholders() string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(t.primaryKey)), ",")
+	if len(t.primaryKey) == 1 {
+		return placeholders
+	}
+	return "(" + placeholders + ")"
+}
+
+// requestedKeyIndex is the column of requestedKeys holding the index of each requested key
+const requestedKeyIndex = "requested_index"
+
+// requestedKeys returns a derived table of the keys, one row per key with its index and the values of its primary key columns, and its bindings.
+// Joined with the table, the database compares the keys with the stored values in its own collation, and each row is returned with the index of the key it matched.
+func (t *table) requestedKeys(keys []string) (string, []interface{}) {
+	selects := make([]string, len(keys))
+	var bindings []interface{}
+	for i, key := range keys {
+		cols := []string{strconv.Itoa(i) + " AS " + requestedKeyIndex}
+		for j := range t.primaryKey {
+			cols = append(cols, fmt.Sprintf("? AS key%d", j))
+		}
+		selects[i] = "SELECT " + strings.Join(cols, ",")
+		bindings = append(bindings, t.keyValues(key)...)
+	}
+	return "(" + strings.Join(selects, " UNION ALL ") + ") AS requested", bindings
+}
+
+// requestedKeyCondition returns the condition joining the table to the derived table of requestedKeys
+func (t *table) requestedKeyCondition() string {
+	conditions := make([]string, len(t.primaryKey))
+	for i, col := range t.primaryKey {
+		conditions[i] = fmt.Sprintf("%s.%s = requested.key%d", t.name, col, i)
+	}
+	return strings.Join(conditions, " AND ")
+}
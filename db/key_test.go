@@ -0,0 +1,48 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitKey(t *testing.T) {
+	assert.Equal(t, []string{"a/b"}, SplitKey("a/b", 1))
+	assert.Equal(t, []string{"a", "b"}, SplitKey("a/b", 2))
+	assert.Equal(t, []string{"a", "b/c"}, SplitKey("a/b/c", 2), "the last value takes the remainder of the key")
+	assert.Equal(t, []string{"a", "", ""}, SplitKey("a", 3))
+	assert.Equal(t, "a/b", JoinKey(SplitKey("a/b", 2)))
+}
+
+func TestCompositeKeyStatements(t *testing.T) {
+	cfg := &config.Config{Paths: map[string]config.Mapping{
+		"/content/:uuid/annotations/:platform": {
+			Table: "annotations",
+			Columns: map[string]config.Column{
+				"uuid":     {Expr: ":uuid"},
+				"platform": {Expr: ":platform"},
+				"body":     {Expr: "$"},
+			},
+			PrimaryKey: config.PrimaryKey{"uuid", "platform"},
+		},
+	}}
+
+	statements, err := DescribeStatements(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT body,hash FROM annotations WHERE uuid = ? AND platform = ?", statements["annotations"].Read)
+	assert.Equal(t, "UPDATE annotations SET hash=?,body=?,platform=?,uuid=? WHERE uuid = ? AND platform = ?", statements["annotations"].Update)
+
+	tables, _, err := mapTables(cfg)
+	require.NoError(t, err)
+	table := tables["annotations"]
+	assert.Equal(t, []interface{}{"1234", "web"}, table.keyValues("1234/web"))
+	assert.Equal(t, "(uuid,platform)", table.keyTuple())
+	assert.Equal(t, "(?,?)", table.keyPlaceholders())
+
+	requested, bindings := table.requestedKeys([]string{"1234/web", "5678/app"})
+	assert.Equal(t, "(SELECT 0 AS requested_index,? AS key0,? AS key1 UNION ALL SELECT 1 AS requested_index,? AS key0,? AS key1) AS requested", requested)
+	assert.Equal(t, []interface{}{"1234", "web", "5678", "app"}, bindings)
+	assert.Equal(t, "annotations.uuid = requested.key0 AND annotations.platform = requested.key1", table.requestedKeyCondition())
+}
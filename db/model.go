@@ -24,3 +24,15 @@ type DocMetadata map[string]string
 func (m DocMetadata) Set(key string, value string) {
 	m[key] = value
 }
+
+// Page is a slice of the documents in a table, in primary key order.
+// Next is the key to list from to get the following page, and is empty on the last page.
+type Page struct {
+	Items []ListItem
+	Next  string
+}
+
+type ListItem struct {
+	Key      string
+	Metadata DocMetadata
+}
@@ -75,6 +75,46 @@ var (
 			`alter table draft_content drop column content_type;
 		`,
 		},
+		{5, "add-history-tables",
+			`create table draft_annotations_history (
+			history_id bigint auto_increment primary key,
+			uuid varchar(36) not null,
+			last_modified varchar(32) not null,
+			publish_ref varchar(50) not null,
+			hash varchar(56) not null,
+			body mediumtext not null,
+			index draft_annotations_history_uuid (uuid, history_id)
+		);
+
+		create table published_annotations_history (
+			history_id bigint auto_increment primary key,
+			uuid varchar(36) not null,
+			last_modified varchar(32) not null,
+			publish_ref varchar(50) not null,
+			hash varchar(56) not null,
+			body mediumtext not null,
+			index published_annotations_history_uuid (uuid, history_id)
+		);
+
+		create table draft_content_history (
+			history_id bigint auto_increment primary key,
+			uuid varchar(36) not null,
+			last_modified varchar(32) not null,
+			draft_ref varchar(50) not null,
+			origin_system varchar(50) not null,
+			content_type varchar(128) not null,
+			hash varchar(56) not null,
+			body mediumtext not null,
+			index draft_content_history_uuid (uuid, history_id)
+		);
+		`,
+			`drop table draft_content_history;
+
+		drop table published_annotations_history;
+
+		drop table draft_annotations_history;
+		`,
+		},
 	}
 	requiredVersion int64
 )
@@ -3,15 +3,15 @@ package db
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/Financial-Times/generic-rw-aurora/expression"
 	tid "github.com/Financial-Times/transactionid-utils-go"
 	"github.com/go-sql-driver/mysql"
-	"github.com/oliveagle/jsonpath"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -21,9 +21,23 @@ const (
 
 const hashColumn = "hash"
 const conflictLogMessage = "document hash conflict detected while updating document"
+const deleteConflictLogMessage = "document hash conflict detected while deleting document"
 
-const Created = true
-const Updated = false
+// WriteStatus is the outcome of a successful write
+type WriteStatus int
+
+const (
+	Created WriteStatus = iota
+	Updated
+	Unchanged
+)
+
+// Special values of previousDocumentHash that request create-only or update-only semantics.
+// Unlike a document hash, they are enforced whether or not the table has conflict detection.
+const (
+	AnyDocumentHash = "*"
+	NoDocumentHash  = "!*"
+)
 
 const contextDocumentKey = "contextDocumentKey"
 const contextTable = "contextTable"
@@ -35,16 +49,39 @@ type RWMonitor interface {
 	SchemaCheck() (string, error)
 }
 
+// sqlExecutor is implemented by both *sql.DB and *sql.Tx, so that writes may take part in a transaction
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 type RWService interface {
 	Read(ctx context.Context, table string, key string) (Document, error)
-	Write(ctx context.Context, table string, key string, doc Document, params map[string]string, previousDocumentHash string) (bool, string, error)
+	ReadMany(ctx context.Context, table string, keys []string) (map[string]Document, error)
+	Write(ctx context.Context, table string, key string, doc Document, params map[string]string, previousDocumentHash string) (WriteStatus, string, error)
+	Patch(ctx context.Context, table string, key string, patch func(body []byte) ([]byte, error), doc Document, params map[string]string, previousDocumentHash string) (WriteStatus, string, error)
+	Delete(ctx context.Context, table string, key string, previousDocumentHash string) error
+	List(ctx context.Context, table string, after string, limit int) (Page, error)
+	BulkWrite(ctx context.Context, table string, items []BulkWriteItem) ([]BulkWriteResult, error)
+	History(ctx context.Context, table string, key string) ([]Version, error)
+	ReadVersion(ctx context.Context, table string, key string, hash string) (Document, error)
+	Restore(ctx context.Context, table string, key string, hash string, doc Document, params map[string]string, previousDocumentHash string) (WriteStatus, string, error)
 }
 
 type table struct {
 	name                 string
-	columns              map[string]string
-	primaryKey           string
+	columns              map[string]config.Column
+	expressions          map[string]expression.Expression
+	types                map[string]columnType
+	primaryKey           []string
 	hasConflictDetection bool
+	conflictPolicy       string
+	skipUnchanged        bool
+	history              bool
+	historyLimit         int
+	listColumns          []string
+	bulkWriteChunkSize   int
+	compressBody         bool
 }
 
 type AuroraRWService struct {
@@ -58,37 +95,161 @@ type AuroraRWService struct {
 func (t *table) columnMapping() string {
 	var mapping string
 	for col, expr := range t.columns {
-		mapping += fmt.Sprintf(",%s->%s", expr, col)
+		mapping += fmt.Sprintf(",%s->%s", expr.Expr, col)
 	}
 
 	return mapping[1:]
 }
 
-func NewService(conn *sql.DB, migrate bool, rwConfig *config.Config) *AuroraRWService {
+// metadata formats the values read from the columns, leaving out NULL values
+func (t *table) metadata(cols []string, vals []sql.NullString) DocMetadata {
+	metadata := DocMetadata{}
+	for i, col := range cols {
+		if vals[i].Valid {
+			metadata.Set(col, t.types[col].fromDatabase(vals[i].String))
+		}
+	}
+	return metadata
+}
+
+// scanDest returns the destinations to scan a row into the values
+func scanDest(vals []sql.NullString) []interface{} {
+	dest := make([]interface{}, len(vals))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+	return dest
+}
+
+// storedColumns returns the hash column and the configured columns of the table, in a stable order
+func (t *table) storedColumns() []string {
+	cols := []string{hashColumn}
+	for col := range t.columns {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols[1:])
+	return cols
+}
+
+func (t *table) readQuery(docQuery documentQuery) string {
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s", strings.Join(docQuery.columns, ","), t.name, t.keyCondition())
+}
+
+func (t *table) insertStatement() string {
+	columns, values := buildInsertComponents(*t)
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.name, columns, values)
+}
+
+func (t *table) upsertStatement() string {
+	return t.insertStatement() + " ON DUPLICATE KEY UPDATE " + buildUpdateSetComponents(*t)
+}
+
+// updateStatement returns the statement updating a stored document, on condition of its previous hash if withHash is set
+func (t *table) updateStatement(withHash bool) string {
+	stmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s", t.name, buildUpdateSetComponents(*t), t.keyCondition())
+	if withHash {
+		stmt += fmt.Sprintf(" AND %s = ?", hashColumn)
+	}
+	return stmt
+}
+
+func NewService(conn *sql.DB, migrate bool, rwConfig *config.Config) (*AuroraRWService, error) {
+	service, err := newService(conn, rwConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.migrate(migrate); err != nil {
+		log.WithError(err).Error("failed to migrate db")
+		service.schemaMismatch = err
+	}
+
+	return service, nil
+}
+
+// newService maps the configured tables, without checking the database
+func newService(conn *sql.DB, rwConfig *config.Config) (*AuroraRWService, error) {
+	tables, responseHeaders, err := mapTables(rwConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &AuroraRWService{conn: conn, rwConfig: tables, httpResponseConfig: responseHeaders}, nil
+}
+
+// mapTables parses the column expressions of every path, so that they are not parsed on every request
+func mapTables(rwConfig *config.Config) (map[string]table, map[string]map[string]string, error) {
 	tables := make(map[string]table)
 	responseHeaders := make(map[string]map[string]string)
 	for _, tableConfig := range rwConfig.Paths {
+		expressions := make(map[string]expression.Expression)
+		types := make(map[string]columnType)
+		for col, column := range tableConfig.Columns {
+			e, err := expression.Parse(column.Expr)
+			if err != nil {
+				return nil, nil, fmt.Errorf("table %s, column %s: invalid expression %q: %v", tableConfig.Table, col, column.Expr, err)
+			}
+			expressions[col] = e
+			types[col] = newColumnType(column)
+		}
+
 		t := table{
 			tableConfig.Table,
 			tableConfig.Columns,
-			tableConfig.PrimaryKey,
+			expressions,
+			types,
+			[]string(tableConfig.PrimaryKey),
 			tableConfig.HasConflictDetection,
+			tableConfig.ConflictPolicy,
+			tableConfig.SkipUnchanged,
+			tableConfig.History,
+			tableConfig.HistoryLimit,
+			tableConfig.List.Columns,
+			tableConfig.BulkWrite.ChunkSize,
+			tableConfig.CompressBody,
+		}
+		if t.conflictPolicy == "" {
+			t.conflictPolicy = config.ConflictPolicyOverwrite
 		}
 		tables[tableConfig.Table] = t
-		log.WithFields(log.Fields{"table": t.name, "primaryKey": t.primaryKey, "columnMapping": t.columnMapping()}).Info("mapping initialised")
+		log.WithFields(log.Fields{"table": t.name, "primaryKey": t.primaryKey, "columnMapping": t.columnMapping(), "conflictPolicy": t.conflictPolicy, "history": t.history, "historyLimit": t.historyLimit, "compressBody": t.compressBody}).Info("mapping initialised")
 
 		if tableConfig.Response.Headers != nil {
 			responseHeaders[tableConfig.Table] = tableConfig.Response.Headers
 		}
 	}
-	service := &AuroraRWService{conn: conn, rwConfig: tables, httpResponseConfig: responseHeaders}
+	return tables, responseHeaders, nil
+}
 
-	if err := service.migrate(migrate); err != nil {
-		log.WithError(err).Error("failed to migrate db")
-		service.schemaMismatch = err
+// Reconfigured returns a service for the table mappings of the configuration, sharing the connection and schema state of this service, whose own mappings are left unchanged.
+// If the database schema is up to date, the configured tables are checked first, and an error is returned if they do not match.
+func (service *AuroraRWService) Reconfigured(rwConfig *config.Config) (RWService, error) {
+	candidate, err := newService(service.conn, rwConfig)
+	if err != nil {
+		return nil, err
 	}
+	candidate.schemaVersion = service.schemaVersion
+	candidate.schemaMismatch = service.schemaMismatch
+	if service.schemaMismatch == nil {
+		if err := candidate.CheckTables(); err != nil {
+			return nil, err
+		}
+	}
+
+	log.WithField("tables", len(candidate.rwConfig)).Info("mappings reconfigured")
+	return candidate, nil
+}
+
+// mappedTable returns the mapping of the table. The mappings of a service are never modified, a reconfiguration creating a new service instead.
+func (service *AuroraRWService) mappedTable(tableName string) table {
+	return service.rwConfig[tableName]
+}
+
+func (service *AuroraRWService) mappedTables() map[string]table {
+	return service.rwConfig
+}
 
-	return service
+func (service *AuroraRWService) responseHeaders(tableName string) map[string]string {
+	return service.httpResponseConfig[tableName]
 }
 
 func (service *AuroraRWService) Ping() (string, error) {
@@ -115,32 +276,18 @@ func (service *AuroraRWService) Read(ctx context.Context, tableName string, key
 		WithField(tid.TransactionIDKey, txid)
 
 	readLog.Info("Reading document from database")
-	table := service.rwConfig[tableName]
-	var docColumn string
-
-	for col, expr := range table.columns {
-		if expr == "$" {
-			docColumn = col
-			break
-		}
-	}
+	table := service.mappedTable(tableName)
 
-	if docColumn == "" {
+	docQuery, err := service.newDocumentQuery(tableName)
+	if err != nil {
 		readLog.Error("document column is not configured")
-		return Document{}, fmt.Errorf("document column is not configured for table %s", tableName)
-	}
-
-	responseHeaderCols := []string{docColumn, hashColumn}
-	sqlToHeaderMap := make(map[string]string)
-	for k, v := range service.httpResponseConfig[tableName] {
-		responseHeaderCols = append(responseHeaderCols, v)
-		sqlToHeaderMap[v] = k
+		return Document{}, err
 	}
 
-	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", strings.Join(responseHeaderCols, ","), table.name, table.primaryKey)
+	query := table.readQuery(docQuery)
 	readLog.Info(query)
 
-	rows, err := service.conn.Query(query, key)
+	rows, err := service.conn.Query(query, table.keyValues(key)...)
 	if err != nil {
 		readLog.WithError(err).Error("unable to read from database")
 		return Document{}, err
@@ -156,84 +303,320 @@ func (service *AuroraRWService) Read(ctx context.Context, tableName string, key
 		return Document{}, sql.ErrNoRows
 	}
 
-	colNames, err := rows.Columns()
+	doc, err := docQuery.scan(rows)
 	if err != nil {
 		readLog.WithError(err).Error("unable to read from database")
 		return Document{}, err
 	}
 
-	var colDoc, colHash int
-	for i := range colNames {
-		switch colNames[i] {
-		case docColumn:
-			colDoc = i
+	return doc, nil
+}
 
-		case hashColumn:
-			colHash = i
+func (service *AuroraRWService) ReadMany(ctx context.Context, tableName string, keys []string) (map[string]Document, error) {
+	ctx = context.WithValue(ctx, contextTable, tableName)
 
-		default:
-		}
+	readLog := buildLogEntryFromContext(ctx).WithField("count", len(keys))
+	readLog.Info("Reading documents from database")
+
+	docs := make(map[string]Document)
+	if len(keys) == 0 {
+		return docs, nil
 	}
 
-	cols := len(responseHeaderCols)
-	vals := make([]interface{}, cols)
-	for i := range vals {
-		vals[i] = new(string)
+	table := service.mappedTable(tableName)
+
+	docQuery, err := service.newDocumentQuery(tableName)
+	if err != nil {
+		readLog.Error("document column is not configured")
+		return nil, err
+	}
+
+	// the rows are mapped back to the keys they matched, which may differ from the stored key values, e.g. in case
+	requested, bindings := table.requestedKeys(keys)
+	columns := make([]string, len(docQuery.columns))
+	for i, col := range docQuery.columns {
+		columns[i] = table.name + "." + col
 	}
-	err = rows.Scan(vals...)
+	query := fmt.Sprintf("SELECT requested.%s,%s FROM %s JOIN %s ON %s", requestedKeyIndex, strings.Join(columns, ","), table.name, requested, table.requestedKeyCondition())
 
+	rows, err := service.conn.Query(query, bindings...)
 	if err != nil {
-		if err != sql.ErrNoRows {
+		readLog.WithError(err).Error("unable to read from database")
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var index int
+		doc, err := docQuery.scan(rows, &index)
+		if err != nil {
 			readLog.WithError(err).Error("unable to read from database")
+			return nil, err
 		}
-		return Document{}, err
+		docs[keys[index]] = doc
+	}
+
+	if err = rows.Err(); err != nil {
+		readLog.WithError(err).Error("unable to read from database")
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// documentQuery describes the columns selected to build a document and its response metadata
+type documentQuery struct {
+	// the document column, the hash column and the response header columns, in this order
+	columns []string
+	// the response header names of columns[2:]
+	headers []string
+	// the types of columns[2:]
+	types []columnType
+}
+
+func (service *AuroraRWService) newDocumentQuery(tableName string) (documentQuery, error) {
+	var docColumn string
+	for col, expr := range service.mappedTable(tableName).expressions {
+		if expression.IsDocument(expr) {
+			docColumn = col
+			break
+		}
+	}
+
+	if docColumn == "" {
+		return documentQuery{}, fmt.Errorf("document column is not configured for table %s", tableName)
+	}
+
+	q := documentQuery{columns: []string{docColumn, hashColumn}}
+	headers := service.responseHeaders(tableName)
+	for header := range headers {
+		q.headers = append(q.headers, header)
+	}
+	sort.Strings(q.headers)
+	types := service.mappedTable(tableName).types
+	for _, header := range q.headers {
+		q.columns = append(q.columns, headers[header])
+		q.types = append(q.types, types[headers[header]])
+	}
+	return q, nil
+}
+
+// scan reads a document from the current row. Any leading values selected before the document columns are scanned into dest.
+func (q documentQuery) scan(rows *sql.Rows, dest ...interface{}) (Document, error) {
+	var body, docHash string
+	vals := []interface{}{&body, &docHash}
+	headerVals := make([]sql.NullString, len(q.headers))
+	for i := range headerVals {
+		vals = append(vals, &headerVals[i])
 	}
 
-	doc := NewDocumentWithHash([]byte(*vals[colDoc].(*string)), *vals[colHash].(*string))
+	if err := rows.Scan(append(dest, vals...)...); err != nil {
+		return Document{}, err
+	}
 
-	for i := 0; i < cols; i++ {
-		if i != colDoc && i != colHash {
-			doc.Metadata.Set(sqlToHeaderMap[responseHeaderCols[i]], *vals[i].(*string))
+	doc := NewDocumentWithHash(decompressBody([]byte(body), docHash), docHash)
+	for i, header := range q.headers {
+		// a NULL column has no header
+		if headerVals[i].Valid {
+			doc.Metadata.Set(header, q.types[i].fromDatabase(headerVals[i].String))
 		}
 	}
 
 	return doc, nil
 }
 
-func (service *AuroraRWService) Write(ctx context.Context, tableName string, key string, doc Document, params map[string]string, previousDocHash string) (bool, string, error) {
+func (service *AuroraRWService) List(ctx context.Context, tableName string, after string, limit int) (Page, error) {
+	ctx = context.WithValue(ctx, contextTable, tableName)
+
+	listLog := buildLogEntryFromContext(ctx).WithField("after", after)
+	listLog.Info("Listing documents from database")
+
+	table := service.mappedTable(tableName)
+	keyCount := len(table.primaryKey)
+	cols := append(append([]string{}, table.primaryKey...), table.listColumns...)
+
+	// read one more row than requested, to find out whether there is a next page
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s > %s ORDER BY %s LIMIT ?", strings.Join(cols, ","), table.name, table.keyTuple(), table.keyPlaceholders(), strings.Join(table.primaryKey, ","))
+	rows, err := service.conn.Query(query, append(table.keyValues(after), limit+1)...)
+	if err != nil {
+		listLog.WithError(err).Error("unable to list from database")
+		return Page{}, err
+	}
+	defer rows.Close()
+
+	page := Page{Items: []ListItem{}}
+	for rows.Next() {
+		if len(page.Items) == limit {
+			page.Next = page.Items[limit-1].Key
+			break
+		}
+
+		vals := make([]sql.NullString, len(cols))
+		if err = rows.Scan(scanDest(vals)...); err != nil {
+			listLog.WithError(err).Error("unable to list from database")
+			return Page{}, err
+		}
+
+		keyValues := make([]string, keyCount)
+		for i := range keyValues {
+			keyValues[i] = vals[i].String
+		}
+		item := ListItem{Key: JoinKey(keyValues), Metadata: table.metadata(table.listColumns, vals[keyCount:])}
+		page.Items = append(page.Items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		listLog.WithError(err).Error("unable to list from database")
+		return Page{}, err
+	}
+
+	return page, nil
+}
+
+func (service *AuroraRWService) Write(ctx context.Context, tableName string, key string, doc Document, params map[string]string, previousDocHash string) (WriteStatus, string, error) {
+	if !service.mappedTable(tableName).history {
+		return service.write(ctx, service.conn, tableName, key, doc, params, previousDocHash)
+	}
+
+	// the superseded document is archived in the same transaction as the write
+	var status WriteStatus
+	var hash string
+	err := service.inTransaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		status, hash, err = service.write(ctx, tx, tableName, key, doc, params, previousDocHash)
+		return err
+	})
+	return status, hash, err
+}
+
+func (service *AuroraRWService) write(ctx context.Context, exec sqlExecutor, tableName string, key string, doc Document, params map[string]string, previousDocHash string) (WriteStatus, string, error) {
 	ctx = context.WithValue(ctx, contextTable, tableName)
 	ctx = context.WithValue(ctx, contextDocumentKey, key)
 
 	writeLog := buildLogEntryFromContext(ctx)
 	writeLog.Info("Writing document to database")
 
-	table := service.rwConfig[tableName]
+	table := service.mappedTable(tableName)
 	doc.Hash = hash(doc.Body)
-	var status bool
-	var err error
-	if table.hasConflictDetection {
+
+	// the values are computed once, so that functions such as now() and uuid() are consistent across the statements of a write
+	values, err := buildColumnValues(ctx, table, key, doc, params)
+	if err != nil {
+		writeLog.WithError(err).Warn("unable to convert column value")
+		return Updated, doc.Hash, err
+	}
+
+	skipUnchanged := table.skipUnchanged && previousDocHash != NoDocumentHash
+	var currentHash string
+	if skipUnchanged || table.history {
+		if currentHash, err = service.currentHash(ctx, exec, table, key); err != nil {
+			return Updated, doc.Hash, err
+		}
+	}
+	if skipUnchanged && currentHash == doc.Hash {
+		writeLog.Info("Document is unchanged, skipping write")
+		return Unchanged, doc.Hash, nil
+	}
+
+	// a document written again as it is stored is not a new version, so it is not archived
+	if table.history && currentHash != "" && currentHash != doc.Hash {
+		if err := service.archiveDocument(ctx, exec, table, key); err != nil {
+			return Updated, doc.Hash, err
+		}
+	}
+
+	var status WriteStatus
+	if previousDocHash == NoDocumentHash {
+		table.conflictPolicy = config.ConflictPolicyReject
+		status, err = service.insertDocumentWithConflictDetection(ctx, exec, table, key, values)
+	} else if previousDocHash == AnyDocumentHash {
+		status, err = service.updateExistingDocument(ctx, exec, table, key, values)
+	} else if table.hasConflictDetection {
 		if previousDocHash == "" {
-			status, err = service.insertDocumentWithConflictDetection(ctx, table, key, doc, params)
+			status, err = service.insertDocumentWithConflictDetection(ctx, exec, table, key, values)
 		} else {
-			status, err = service.updateDocumentWithConflictDetection(ctx, table, key, doc, params, previousDocHash)
+			status, err = service.updateDocumentWithConflictDetection(ctx, exec, table, key, values, previousDocHash)
 		}
 	} else {
-		status, err = service.insertDocumentOnDuplicateKeyUpdate(ctx, table, key, doc, params)
+		status, err = service.insertDocumentOnDuplicateKeyUpdate(ctx, exec, table, key, values)
 	}
 	return status, doc.Hash, err
 }
 
-func (service *AuroraRWService) insertDocumentWithConflictDetection(ctx context.Context, t table, key string, doc Document, params map[string]string) (bool, error) {
-	writeLog := buildLogEntryFromContext(ctx)
-	columns, values, bindings := buildInsertComponents(ctx, t, key, doc, params)
-	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.name, columns, values)
+// Patch applies the patch function to the stored document and writes the result, with the metadata of doc, in a single transaction.
+// The stored row is locked while the patch is applied, so that concurrent patches are applied one after the other.
+func (service *AuroraRWService) Patch(ctx context.Context, tableName string, key string, patch func(body []byte) ([]byte, error), doc Document, params map[string]string, previousDocHash string) (WriteStatus, string, error) {
+	ctx = context.WithValue(ctx, contextTable, tableName)
+	ctx = context.WithValue(ctx, contextDocumentKey, key)
+
+	patchLog := buildLogEntryFromContext(ctx)
+	patchLog.Info("Patching document in database")
+
+	table := service.mappedTable(tableName)
+	docQuery, err := service.newDocumentQuery(tableName)
+	if err != nil {
+		patchLog.Error("document column is not configured")
+		return Updated, "", err
+	}
+
+	tx, err := service.conn.BeginTx(ctx, nil)
+	if err != nil {
+		patchLog.WithError(err).Error("unable to start transaction")
+		return Updated, "", err
+	}
+	defer tx.Rollback()
+
+	var body []byte
+	var currentHash string
+	query := fmt.Sprintf("SELECT %s,%s FROM %s WHERE %s FOR UPDATE", docQuery.columns[0], hashColumn, table.name, table.keyCondition())
+	err = tx.QueryRow(query, table.keyValues(key)...).Scan(&body, &currentHash)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			patchLog.WithError(err).Error("unable to read from database")
+		}
+		return Updated, "", err
+	}
+	body = decompressBody(body, currentHash)
+
+	if previousDocHash == NoDocumentHash {
+		return Updated, "", &ConflictError{CurrentHash: currentHash}
+	}
+	if table.hasConflictDetection && previousDocHash != "" && previousDocHash != AnyDocumentHash && previousDocHash != currentHash {
+		patchLog.Warn(conflictLogMessage)
+		if table.conflictPolicy == config.ConflictPolicyReject {
+			return Updated, "", &ConflictError{CurrentHash: currentHash}
+		}
+	}
+
+	doc.Body, err = patch(body)
+	if err != nil {
+		patchLog.WithError(err).Warn("unable to apply patch to document")
+		return Updated, "", &PatchError{Err: err}
+	}
+
+	status, hash, err := service.write(ctx, tx, tableName, key, doc, params, currentHash)
+	if err != nil {
+		return status, hash, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		patchLog.WithError(err).Error("unable to commit transaction")
+		return status, hash, err
+	}
+	return status, hash, nil
+}
 
-	_, err := service.executeStatement(insert, bindings)
+func (service *AuroraRWService) insertDocumentWithConflictDetection(ctx context.Context, exec sqlExecutor, t table, key string, values []interface{}) (WriteStatus, error) {
+	writeLog := buildLogEntryFromContext(ctx)
+	_, err := service.executeStatement(exec, t.insertStatement(), values)
 	if err != nil {
 		if mysqlErr, ok := err.(*mysql.MySQLError); ok {
 			if mysqlErr.Number == 1062 {
 				writeLog.Warn(conflictLogMessage)
-				return service.insertDocumentOnDuplicateKeyUpdate(ctx, t, key, doc, params)
+				if t.conflictPolicy == config.ConflictPolicyReject {
+					return Updated, service.conflictError(ctx, exec, t, key)
+				}
+				return service.insertDocumentOnDuplicateKeyUpdate(ctx, exec, t, key, values)
 			}
 		}
 		writeLog.WithError(err).Error("unable to write to database")
@@ -241,32 +624,62 @@ func (service *AuroraRWService) insertDocumentWithConflictDetection(ctx context.
 	return Created, err
 }
 
-func (service *AuroraRWService) updateDocumentWithConflictDetection(ctx context.Context, t table, key string, doc Document, params map[string]string, previousDocHash string) (bool, error) {
+func (service *AuroraRWService) updateDocumentWithConflictDetection(ctx context.Context, exec sqlExecutor, t table, key string, values []interface{}, previousDocHash string) (WriteStatus, error) {
 	writeLog := buildLogEntryFromContext(ctx)
 
-	setStmt, values := buildUpdateSetComponents(ctx, t, key, doc, params)
-	bindings := append(values, key, previousDocHash)
-	updateStmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ? AND %s = ?", t.name, setStmt, t.primaryKey, hashColumn)
-	affectedRows, err := service.executeStatement(updateStmt, bindings)
+	bindings := append(append(append([]interface{}{}, values...), t.keyValues(key)...), previousDocHash)
+	affectedRows, err := service.executeStatement(exec, t.updateStatement(true), bindings)
 	if err != nil {
 		writeLog.WithError(err).Error("unable to write to database")
+		return Updated, err
 	}
 	if affectedRows == 0 {
+		// MySQL does not count rows whose values were unchanged, so check whether the stored document really has another hash
+		currentHash, err := service.currentHash(ctx, exec, t, key)
+		if err != nil {
+			return Updated, err
+		}
+		if currentHash == previousDocHash {
+			return Updated, nil
+		}
 		writeLog.Warn(conflictLogMessage)
-		return service.insertDocumentOnDuplicateKeyUpdate(ctx, t, key, doc, params)
+		if t.conflictPolicy == config.ConflictPolicyReject {
+			return Updated, &ConflictError{CurrentHash: currentHash}
+		}
+		return service.insertDocumentOnDuplicateKeyUpdate(ctx, exec, t, key, values)
 	}
-	return Updated, err
+	return Updated, nil
+}
+
+func (service *AuroraRWService) updateExistingDocument(ctx context.Context, exec sqlExecutor, t table, key string, values []interface{}) (WriteStatus, error) {
+	writeLog := buildLogEntryFromContext(ctx)
+
+	bindings := append(append([]interface{}{}, values...), t.keyValues(key)...)
+	affectedRows, err := service.executeStatement(exec, t.updateStatement(false), bindings)
+	if err != nil {
+		writeLog.WithError(err).Error("unable to write to database")
+		return Updated, err
+	}
+	if affectedRows == 0 {
+		// MySQL does not count rows whose values were unchanged, so check whether the document is really missing
+		currentHash, err := service.currentHash(ctx, exec, t, key)
+		if err != nil {
+			return Updated, err
+		}
+		if currentHash == "" {
+			writeLog.Warn("document to update does not exist")
+			return Updated, &ConflictError{}
+		}
+	}
+	return Updated, nil
 }
 
-func (service *AuroraRWService) insertDocumentOnDuplicateKeyUpdate(ctx context.Context, t table, key string, doc Document, params map[string]string) (bool, error) {
+func (service *AuroraRWService) insertDocumentOnDuplicateKeyUpdate(ctx context.Context, exec sqlExecutor, t table, key string, values []interface{}) (WriteStatus, error) {
 	writeLog := buildLogEntryFromContext(ctx)
-	columns, valuesStmt, insertBindings := buildInsertComponents(ctx, t, key, doc, params)
-	setStmt, values := buildUpdateSetComponents(ctx, t, key, doc, params)
-	bindings := append(insertBindings, values...)
+	// the values are bound once for the insert and once for the update
+	bindings := append(append([]interface{}{}, values...), values...)
 
-	insertStmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.name, columns, valuesStmt)
-	insertStmt += " ON DUPLICATE KEY UPDATE " + setStmt
-	affectedRows, err := service.executeStatement(insertStmt, bindings)
+	affectedRows, err := service.executeStatement(exec, t.upsertStatement(), bindings)
 	if err != nil {
 		writeLog.WithError(err).Error("Error in writing ")
 	}
@@ -276,72 +689,201 @@ func (service *AuroraRWService) insertDocumentOnDuplicateKeyUpdate(ctx context.C
 	return Updated, err
 }
 
-func buildInsertComponents(ctx context.Context, t table, key string, doc Document, params map[string]string) (string, string, []interface{}) {
-	valuesMap := generateColumnValuesMap(ctx, t, key, doc, params)
-	insertCols := ""
-	valuesStmt := ""
-	var bindings []interface{}
-	for col, val := range valuesMap {
-		insertCols += "," + col
-		valuesStmt += ",?"
-		bindings = append(bindings, val)
+func (service *AuroraRWService) Delete(ctx context.Context, tableName string, key string, previousDocHash string) error {
+	ctx = context.WithValue(ctx, contextTable, tableName)
+	ctx = context.WithValue(ctx, contextDocumentKey, key)
+
+	deleteLog := buildLogEntryFromContext(ctx)
+	deleteLog.Info("Deleting document from database")
+
+	table := service.mappedTable(tableName)
+	if !table.history {
+		return service.delete(ctx, service.conn, table, key, previousDocHash)
+	}
+
+	return service.inTransaction(ctx, func(tx *sql.Tx) error {
+		if err := service.archiveDocument(ctx, tx, table, key); err != nil {
+			return err
+		}
+		return service.delete(ctx, tx, table, key, previousDocHash)
+	})
+}
+
+func (service *AuroraRWService) delete(ctx context.Context, exec sqlExecutor, table table, key string, previousDocHash string) error {
+	if previousDocHash == AnyDocumentHash {
+		err := service.deleteDocument(ctx, exec, table, key)
+		if err == sql.ErrNoRows {
+			return &ConflictError{}
+		}
+		return err
+	}
+	if table.hasConflictDetection && previousDocHash != "" {
+		return service.deleteDocumentWithConflictDetection(ctx, exec, table, key, previousDocHash)
+	}
+	return service.deleteDocument(ctx, exec, table, key)
+}
+
+func (service *AuroraRWService) deleteDocumentWithConflictDetection(ctx context.Context, exec sqlExecutor, t table, key string, previousDocHash string) error {
+	deleteLog := buildLogEntryFromContext(ctx)
+
+	deleteStmt := fmt.Sprintf("DELETE FROM %s WHERE %s AND %s = ?", t.name, t.keyCondition(), hashColumn)
+	affectedRows, err := service.executeStatement(exec, deleteStmt, append(t.keyValues(key), previousDocHash))
+	if err != nil {
+		deleteLog.WithError(err).Error("unable to delete from database")
+		return err
+	}
+	if affectedRows == 0 {
+		// unlike an overwrite, a delete cannot be undone, so a conflict is rejected whatever the conflict policy
+		deleteLog.Warn(deleteConflictLogMessage)
+		return service.conflictError(ctx, exec, t, key)
+	}
+	return nil
+}
+
+// conflictError builds a ConflictError carrying the hash of the document currently stored for the key
+func (service *AuroraRWService) conflictError(ctx context.Context, exec sqlExecutor, t table, key string) error {
+	currentHash, err := service.currentHash(ctx, exec, t, key)
+	if err != nil {
+		return err
+	}
+	return &ConflictError{CurrentHash: currentHash}
+}
+
+// currentHash returns the hash of the document currently stored for the key, or an empty string if there is none
+func (service *AuroraRWService) currentHash(ctx context.Context, exec sqlExecutor, t table, key string) (string, error) {
+	hashLog := buildLogEntryFromContext(ctx)
+
+	var currentHash string
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s", hashColumn, t.name, t.keyCondition())
+	err := exec.QueryRow(query, t.keyValues(key)...).Scan(&currentHash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		hashLog.WithError(err).Error("unable to read current document hash from database")
+		return "", err
+	}
+	return currentHash, nil
+}
+
+func (service *AuroraRWService) deleteDocument(ctx context.Context, exec sqlExecutor, t table, key string) error {
+	deleteLog := buildLogEntryFromContext(ctx)
+
+	deleteStmt := fmt.Sprintf("DELETE FROM %s WHERE %s", t.name, t.keyCondition())
+	affectedRows, err := service.executeStatement(exec, deleteStmt, t.keyValues(key))
+	if err != nil {
+		deleteLog.WithError(err).Error("unable to delete from database")
+		return err
 	}
-	return insertCols[1:], valuesStmt[1:], bindings
+	if affectedRows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func buildInsertComponents(t table) (string, string) {
+	cols := t.storedColumns()
+	return strings.Join(cols, ","), strings.TrimSuffix(strings.Repeat("?,", len(cols)), ",")
 }
 
-func buildUpdateSetComponents(ctx context.Context, t table, key string, doc Document, params map[string]string) (string, []interface{}) {
-	valuesMap := generateColumnValuesMap(ctx, t, key, doc, params)
-	setStmt := ""
+func buildUpdateSetComponents(t table) string {
+	cols := t.storedColumns()
+	return strings.Join(cols, "=?,") + "=?"
+}
+
+// buildColumnValues returns the values of the stored columns of the table for the document, in the order of the insert and update statements
+func buildColumnValues(ctx context.Context, t table, key string, doc Document, params map[string]string) ([]interface{}, error) {
+	valuesMap, err := generateColumnValuesMap(ctx, t, key, doc, params)
+	if err != nil {
+		return nil, err
+	}
 	var values []interface{}
-	for col, val := range valuesMap {
-		setStmt += "," + col + "=?"
-		values = append(values, val)
+	for _, col := range t.storedColumns() {
+		values = append(values, valuesMap[col])
 	}
-	return setStmt[1:], values
+	return values, nil
 }
 
-func generateColumnValuesMap(ctx context.Context, table table, key string, doc Document, params map[string]string) map[string]interface{} {
+func generateColumnValuesMap(ctx context.Context, table table, key string, doc Document, params map[string]string) (map[string]interface{}, error) {
 	writeLog := buildLogEntryFromContext(ctx)
 
 	values := make(map[string]interface{})
-	var jsondoc interface{}
-
-	for col, expr := range table.columns {
-		var val interface{}
-		if strings.HasPrefix(expr, ":") {
-			// : - in the request params, e.g. :id
-			val = params[expr[1:]]
-		} else if strings.HasPrefix(expr, "@.") {
-			// @. - in the metadata, e.g. @.timestamp
-			val = doc.Metadata[expr[2:]]
-		} else if expr == "$" {
-			// $ - the whole document
-			val = doc.Body
-		} else if strings.HasPrefix(expr, "$") {
-			// $. - a JSONpath in the document, e.g. $.post.body
-			// only unmarshal into a JSON document if necessary, and only once
-			if jsondoc == nil {
-				json.Unmarshal(doc.Body, &jsondoc)
+	in := &expression.Input{Params: params, Metadata: doc.Metadata, Body: doc.Body}
+
+	var missing []string
+	for col, expr := range table.expressions {
+		column := table.columns[col]
+		val, err := expr.Eval(in)
+		if isMissing(val) {
+			switch {
+			case column.Default != "":
+				val = column.Default
+				err = nil
+			case column.Required:
+				missing = append(missing, fmt.Sprintf("%s (%s)", col, expr.String()))
+				continue
 			}
-			var err error
-			val, err = jsonpath.JsonPathLookup(jsondoc, expr)
-			if err != nil {
-				writeLog.WithFields(log.Fields{"column": col, "expr": expr}).Warn("unable to extract JSONPath value from document")
+		}
+		if err != nil {
+			writeLog.WithError(err).WithFields(log.Fields{"column": col, "expr": expr.String()}).Warn("unable to evaluate column expression for document")
+		}
+
+		colType := table.types[col]
+		if values[col], err = colType.toDatabase(val); err != nil {
+			return nil, &ValueError{Column: col, Type: colType.name, Err: err}
+		}
+		// the expressions of other columns, and the hash, are evaluated against the uncompressed body
+		if table.compressBody && expression.IsDocument(expr) && values[col] != nil {
+			if values[col], err = compressBody([]byte(stringValue(values[col]))); err != nil {
+				return nil, err
 			}
-		} else {
-			// a literal value
-			val = expr
 		}
-		values[col] = val
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, &MissingValuesError{Columns: missing}
 	}
 
 	values[hashColumn] = doc.Hash
 
-	return values
+	return values, nil
+}
+
+// isMissing returns whether the value of an expression is missing or empty
+func isMissing(val interface{}) bool {
+	switch val := val.(type) {
+	case nil:
+		return true
+	case string:
+		return val == ""
+	case []byte:
+		return len(val) == 0
+	}
+	return false
+}
+
+// inTransaction calls fn in a new transaction, which is committed if fn succeeds and rolled back otherwise
+func (service *AuroraRWService) inTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := service.conn.BeginTx(ctx, nil)
+	if err != nil {
+		buildLogEntryFromContext(ctx).WithError(err).Error("unable to start transaction")
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		buildLogEntryFromContext(ctx).WithError(err).Error("unable to commit transaction")
+	}
+	return err
 }
 
-func (service *AuroraRWService) executeStatement(stmt string, bindings []interface{}) (int64, error) {
-	res, err := service.conn.Exec(stmt, bindings...)
+func (service *AuroraRWService) executeStatement(exec sqlExecutor, stmt string, bindings []interface{}) (int64, error) {
+	res, err := exec.Exec(stmt, bindings...)
 	if err != nil {
 		return 0, err
 	}
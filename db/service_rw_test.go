@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -32,9 +33,10 @@ const (
 
 type ServiceRWTestSuite struct {
 	suite.Suite
-	dbAdminUrl string
-	dbConn     *sql.DB
-	service    *AuroraRWService
+	dbAdminUrl       string
+	dbConn           *sql.DB
+	service          *AuroraRWService
+	rejectingService *AuroraRWService
 }
 
 func TestServiceRWTestSuite(t *testing.T) {
@@ -71,7 +73,17 @@ func (s *ServiceRWTestSuite) SetupSuite() {
 
 	s.dbConn = conn
 	s.dbConn.SetMaxIdleConns(0)
-	s.service = NewService(conn, true, cfg)
+	s.service, err = NewService(conn, true, cfg)
+	require.NoError(s.T(), err)
+
+	rejectingCfg, err := config.ReadConfig("../config.yml")
+	require.NoError(s.T(), err)
+	for path, mapping := range rejectingCfg.Paths {
+		mapping.ConflictPolicy = config.ConflictPolicyReject
+		rejectingCfg.Paths[path] = mapping
+	}
+	s.rejectingService, err = NewService(conn, false, rejectingCfg)
+	require.NoError(s.T(), err)
 }
 
 func (s *ServiceRWTestSuite) TearDownSuite() {
@@ -139,6 +151,45 @@ func (s *ServiceRWTestSuite) TestReadWithMetadata() {
 	assert.Equal(s.T(), testSystem, actual.Metadata[testHeader])
 }
 
+func (s *ServiceRWTestSuite) TestReadMany() {
+	testTID := "tid_testreadmany"
+	testSystem := "foo-bar-baz"
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	expectedHashes := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		testKey := uuid.NewV4().String()
+
+		testDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, testKey)))
+		testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+		testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+		testDoc.Metadata.Set("x-origin-system-id", testSystem)
+		testDoc.Metadata.Set("content-type", "application/json")
+
+		_, docHash, err := s.service.Write(testCtx, testTableWithMetadata, testKey, testDoc, map[string]string{"id": testKey}, "")
+		require.NoError(s.T(), err)
+		expectedHashes[testKey] = docHash
+	}
+
+	keys := []string{uuid.NewV4().String()}
+	for key := range expectedHashes {
+		keys = append(keys, key)
+	}
+	// the key column compares case-insensitively, so a key in upper case matches the stored key
+	upperKey := strings.ToUpper(keys[1])
+	keys = append(keys, upperKey)
+
+	actual, err := s.service.ReadMany(testCtx, testTableWithMetadata, keys)
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), actual, len(expectedHashes)+1)
+	for key, expectedHash := range expectedHashes {
+		assert.Equal(s.T(), fmt.Sprintf(testDocTemplate, key), string(actual[key].Body), "document read from store")
+		assert.Equal(s.T(), expectedHash, actual[key].Hash)
+		assert.Equal(s.T(), testSystem, actual[key].Metadata["X-Origin-System-Id"])
+	}
+	assert.Equal(s.T(), expectedHashes[keys[1]], actual[upperKey].Hash, "a document is returned under the key requested")
+}
+
 func (s *ServiceRWTestSuite) TestWriteCreateWithoutConflictDetection() {
 	testKey := uuid.NewV4().String()
 	testLastModified := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
@@ -375,6 +426,502 @@ func (s *ServiceRWTestSuite) TestUpdateWithConflict() {
 	assert.Equal(s.T(), testTID2, hook.LastEntry().Data[tid.TransactionIDKey])
 }
 
+func (s *ServiceRWTestSuite) TestDelete() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testdelete"
+
+	testDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, time.Now().String())))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, _, err := s.service.Write(testCtx, testTable, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	err = s.service.Delete(testCtx, testTable, testKey, "")
+	assert.NoError(s.T(), err)
+
+	_, err = s.service.Read(testCtx, testTable, testKey)
+	assert.EqualError(s.T(), err, sql.ErrNoRows.Error())
+}
+
+func (s *ServiceRWTestSuite) TestDeleteNotFound() {
+	testKey := uuid.NewV4().String()
+	testCtx := tid.TransactionAwareContext(context.Background(), "tid_testdelete")
+
+	err := s.service.Delete(testCtx, testTable, testKey, "")
+	assert.EqualError(s.T(), err, sql.ErrNoRows.Error())
+}
+
+func (s *ServiceRWTestSuite) TestDeleteWithoutConflict() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testdelete"
+
+	testDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, time.Now().String())))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, docHash, err := s.service.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	err = s.service.Delete(testCtx, testTableWithConflictDetection, testKey, docHash)
+	assert.NoError(s.T(), err)
+
+	_, err = s.service.Read(testCtx, testTableWithConflictDetection, testKey)
+	assert.EqualError(s.T(), err, sql.ErrNoRows.Error())
+}
+
+func (s *ServiceRWTestSuite) TestDeleteWithConflict() {
+	hook := logTest.NewGlobal()
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testdelete"
+
+	testDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, time.Now().String())))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, docHash, err := s.service.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	aVeryOldHash := "01234567890123456789012345678901234567890123456789012345"
+
+	err = s.service.Delete(testCtx, testTableWithConflictDetection, testKey, aVeryOldHash)
+	require.IsType(s.T(), &ConflictError{}, err, "a stale hash is rejected even with the overwrite policy")
+	assert.Equal(s.T(), docHash, err.(*ConflictError).CurrentHash)
+
+	actual, err := s.service.Read(testCtx, testTableWithConflictDetection, testKey)
+	assert.NoError(s.T(), err, "the document is not deleted")
+	assert.Equal(s.T(), docHash, actual.Hash)
+
+	var conflictEntry *logrus.Entry
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == "document hash conflict detected while deleting document" {
+			conflictEntry = entry
+		}
+	}
+	require.NotNil(s.T(), conflictEntry, "conflict log entry")
+	assert.Equal(s.T(), logrus.WarnLevel, conflictEntry.Level)
+	assert.Equal(s.T(), testKey, conflictEntry.Data["key"])
+	assert.Equal(s.T(), testTableWithConflictDetection, conflictEntry.Data["table"])
+}
+
+func (s *ServiceRWTestSuite) TestWriteCreateWithRejectedConflict() {
+	testKey := uuid.NewV4().String()
+	testTID1 := "tid_testcreate_1"
+	testTID2 := "tid_testcreate_2"
+
+	testDocBody := fmt.Sprintf(testDocTemplate, time.Now().String())
+	testDoc := NewDocument([]byte(testDocBody))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID1)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID1)
+
+	status, docHash, err := s.rejectingService.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), Created, status)
+
+	staleDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, "stale")))
+	staleDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	staleDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID2)
+
+	testCtx = tid.TransactionAwareContext(context.Background(), testTID2)
+
+	_, _, err = s.rejectingService.Write(testCtx, testTableWithConflictDetection, testKey, staleDoc, params, "")
+	require.IsType(s.T(), &ConflictError{}, err)
+	assert.Equal(s.T(), docHash, err.(*ConflictError).CurrentHash)
+
+	expectedValuePerCol := map[string]string{
+		testDocColumn:    testDocBody,
+		publishRefColumn: testTID1,
+		hashColumn:       docHash,
+	}
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTableWithConflictDetection, expectedValuePerCol)
+}
+
+func (s *ServiceRWTestSuite) TestUpdateWithRejectedConflict() {
+	testKey := uuid.NewV4().String()
+	testTID1 := "tid_testupdate_1"
+	testTID2 := "tid_testupdate_2"
+
+	testDocBody := fmt.Sprintf(testDocTemplate, time.Now().String())
+	testDoc := NewDocument([]byte(testDocBody))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID1)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID1)
+
+	_, docHash, err := s.rejectingService.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	staleDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, "stale")))
+	staleDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	staleDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID2)
+
+	aVeryOldHash := "01234567890123456789012345678901234567890123456789012345"
+
+	testCtx = tid.TransactionAwareContext(context.Background(), testTID2)
+
+	_, _, err = s.rejectingService.Write(testCtx, testTableWithConflictDetection, testKey, staleDoc, params, aVeryOldHash)
+	require.IsType(s.T(), &ConflictError{}, err)
+	assert.Equal(s.T(), docHash, err.(*ConflictError).CurrentHash)
+
+	expectedValuePerCol := map[string]string{
+		testDocColumn:    testDocBody,
+		publishRefColumn: testTID1,
+		hashColumn:       docHash,
+	}
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTableWithConflictDetection, expectedValuePerCol)
+}
+
+func (s *ServiceRWTestSuite) TestUpdateMissingDocumentWithRejectedConflict() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testupdate"
+
+	testDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, time.Now().String())))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+	aVeryOldHash := "01234567890123456789012345678901234567890123456789012345"
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, _, err := s.rejectingService.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, aVeryOldHash)
+	require.IsType(s.T(), &ConflictError{}, err)
+	assert.Empty(s.T(), err.(*ConflictError).CurrentHash)
+
+	_, err = s.rejectingService.Read(testCtx, testTableWithConflictDetection, testKey)
+	assert.EqualError(s.T(), err, sql.ErrNoRows.Error())
+}
+
+func (s *ServiceRWTestSuite) TestUpdateUnchangedWithRejectedConflict() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testupdate"
+
+	testDocBody := fmt.Sprintf(testDocTemplate, time.Now().String())
+	testDoc := NewDocument([]byte(testDocBody))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, docHash, err := s.rejectingService.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	// the same values are written again, so MySQL reports no affected rows
+	status, hash, err := s.rejectingService.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, docHash)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), Updated, status)
+	assert.Equal(s.T(), docHash, hash)
+
+	expectedValuePerCol := map[string]string{
+		testDocColumn:    testDocBody,
+		publishRefColumn: testTID,
+		hashColumn:       docHash,
+	}
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTableWithConflictDetection, expectedValuePerCol)
+}
+
+func (s *ServiceRWTestSuite) TestDeleteWithRejectedConflict() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testdelete"
+
+	testDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, time.Now().String())))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, docHash, err := s.rejectingService.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	aVeryOldHash := "01234567890123456789012345678901234567890123456789012345"
+
+	err = s.rejectingService.Delete(testCtx, testTableWithConflictDetection, testKey, aVeryOldHash)
+	require.IsType(s.T(), &ConflictError{}, err)
+	assert.Equal(s.T(), docHash, err.(*ConflictError).CurrentHash)
+
+	actual, err := s.rejectingService.Read(testCtx, testTableWithConflictDetection, testKey)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), docHash, actual.Hash)
+}
+
+func (s *ServiceRWTestSuite) TestWriteCreateOnly() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testcreateonly"
+
+	testDocBody := fmt.Sprintf(testDocTemplate, time.Now().String())
+	testDoc := NewDocument([]byte(testDocBody))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	status, docHash, err := s.service.Write(testCtx, testTable, testKey, testDoc, params, NoDocumentHash)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), Created, status)
+
+	otherDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, "other")))
+	otherDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	otherDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	_, _, err = s.service.Write(testCtx, testTable, testKey, otherDoc, params, NoDocumentHash)
+	require.IsType(s.T(), &ConflictError{}, err)
+	assert.Equal(s.T(), docHash, err.(*ConflictError).CurrentHash)
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTable, map[string]string{testDocColumn: testDocBody})
+}
+
+func (s *ServiceRWTestSuite) TestWriteUpdateOnly() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testupdateonly"
+
+	testDocBody := fmt.Sprintf(testDocTemplate, time.Now().String())
+	testDoc := NewDocument([]byte(testDocBody))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, _, err := s.service.Write(testCtx, testTable, testKey, testDoc, params, AnyDocumentHash)
+	require.IsType(s.T(), &ConflictError{}, err)
+	assert.Empty(s.T(), err.(*ConflictError).CurrentHash)
+
+	_, _, err = s.service.Write(testCtx, testTable, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	updatedDocBody := fmt.Sprintf(testDocTemplate, "updated")
+	updatedDoc := NewDocument([]byte(updatedDocBody))
+	updatedDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	updatedDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	status, docHash, err := s.service.Write(testCtx, testTable, testKey, updatedDoc, params, AnyDocumentHash)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), Updated, status)
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTable, map[string]string{testDocColumn: updatedDocBody, hashColumn: docHash})
+}
+
+func (s *ServiceRWTestSuite) TestWriteUnchanged() {
+	cfg, err := config.ReadConfig("../config.yml")
+	require.NoError(s.T(), err)
+	for path, mapping := range cfg.Paths {
+		mapping.SkipUnchanged = true
+		cfg.Paths[path] = mapping
+	}
+	service, err := NewService(s.dbConn, false, cfg)
+	require.NoError(s.T(), err)
+
+	testKey := uuid.NewV4().String()
+	testTID1 := "tid_testunchanged_1"
+	testTID2 := "tid_testunchanged_2"
+
+	testDocBody := fmt.Sprintf(testDocTemplate, time.Now().String())
+	testLastModified := time.Now().Truncate(time.Hour).UTC().Format("2006-01-02T15:04:05.000Z")
+	testDoc := NewDocument([]byte(testDocBody))
+	testDoc.Metadata.Set(timestampMetadata, testLastModified)
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID1)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID1)
+
+	status, docHash, err := service.Write(testCtx, testTable, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), Created, status)
+
+	republishedDoc := NewDocument([]byte(testDocBody))
+	republishedDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	republishedDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID2)
+
+	testCtx = tid.TransactionAwareContext(context.Background(), testTID2)
+
+	status, republishedHash, err := service.Write(testCtx, testTable, testKey, republishedDoc, params, "")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), Unchanged, status)
+	assert.Equal(s.T(), docHash, republishedHash)
+
+	expectedValuePerCol := map[string]string{
+		testDocColumn:      testDocBody,
+		lastModifiedColumn: testLastModified,
+		publishRefColumn:   testTID1,
+		hashColumn:         docHash,
+	}
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTable, expectedValuePerCol)
+}
+
+func (s *ServiceRWTestSuite) TestList() {
+	cfg, err := config.ReadConfig("../config.yml")
+	require.NoError(s.T(), err)
+	mapping := cfg.Paths["/drafts/content/:id"]
+	mapping.List = config.ListMapping{Enabled: true, Columns: []string{"origin_system"}}
+	cfg.Paths["/drafts/content/:id"] = mapping
+	service, err := NewService(s.dbConn, false, cfg)
+	require.NoError(s.T(), err)
+
+	testTID := "tid_testlist"
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	// keys sharing a random prefix sort together, after the prefix itself
+	prefix := uuid.NewV4().String()[:30]
+	var keys []string
+	for i := 0; i < 3; i++ {
+		testKey := fmt.Sprintf("%s-%d", prefix, i)
+		keys = append(keys, testKey)
+
+		testDoc := NewDocument([]byte(fmt.Sprintf(testDocTemplate, testKey)))
+		testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+		testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+		testDoc.Metadata.Set("x-origin-system-id", "list-system")
+		testDoc.Metadata.Set("content-type", "application/json")
+
+		_, _, err := service.Write(testCtx, testTableWithMetadata, testKey, testDoc, map[string]string{"id": testKey}, "")
+		require.NoError(s.T(), err)
+	}
+
+	page, err := service.List(testCtx, testTableWithMetadata, prefix, 2)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), page.Items, 2)
+	assert.Equal(s.T(), keys[0], page.Items[0].Key)
+	assert.Equal(s.T(), keys[1], page.Items[1].Key)
+	assert.Equal(s.T(), "list-system", page.Items[0].Metadata["origin_system"])
+	assert.Equal(s.T(), keys[1], page.Next)
+
+	page, err = service.List(testCtx, testTableWithMetadata, page.Next, 1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), page.Items, 1)
+	assert.Equal(s.T(), keys[2], page.Items[0].Key)
+}
+
+func (s *ServiceRWTestSuite) TestPatch() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testpatch"
+
+	testDoc := NewDocument([]byte(`{"foo":"bar","baz":1}`))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, previousDocHash, err := s.service.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	patchedBody := `{"foo":"qux"}`
+	patchMetadata := NewDocument(nil)
+	patchMetadata.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	patchMetadata.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	var patchedFrom string
+	patch := func(body []byte) ([]byte, error) {
+		patchedFrom = string(body)
+		return []byte(patchedBody), nil
+	}
+
+	status, docHash, err := s.service.Patch(testCtx, testTableWithConflictDetection, testKey, patch, patchMetadata, params, previousDocHash)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), Updated, status)
+	assert.Equal(s.T(), `{"foo":"bar","baz":1}`, patchedFrom)
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTableWithConflictDetection, map[string]string{testDocColumn: patchedBody, hashColumn: docHash})
+}
+
+func (s *ServiceRWTestSuite) TestPatchNotFound() {
+	testKey := uuid.NewV4().String()
+	testCtx := tid.TransactionAwareContext(context.Background(), "tid_testpatch")
+
+	patch := func(body []byte) ([]byte, error) {
+		return body, nil
+	}
+
+	_, _, err := s.service.Patch(testCtx, testTable, testKey, patch, NewDocument(nil), map[string]string{"id": testKey}, "")
+	assert.EqualError(s.T(), err, sql.ErrNoRows.Error())
+}
+
+func (s *ServiceRWTestSuite) TestPatchWithRejectedConflict() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testpatch"
+
+	testDocBody := `{"foo":"bar"}`
+	testDoc := NewDocument([]byte(testDocBody))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, docHash, err := s.rejectingService.Write(testCtx, testTableWithConflictDetection, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	patch := func(body []byte) ([]byte, error) {
+		return []byte(`{"foo":"qux"}`), nil
+	}
+
+	aVeryOldHash := "01234567890123456789012345678901234567890123456789012345"
+
+	_, _, err = s.rejectingService.Patch(testCtx, testTableWithConflictDetection, testKey, patch, NewDocument(nil), params, aVeryOldHash)
+	require.IsType(s.T(), &ConflictError{}, err)
+	assert.Equal(s.T(), docHash, err.(*ConflictError).CurrentHash)
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTableWithConflictDetection, map[string]string{testDocColumn: testDocBody, hashColumn: docHash})
+}
+
+func (s *ServiceRWTestSuite) TestPatchFailure() {
+	testKey := uuid.NewV4().String()
+	testTID := "tid_testpatch"
+
+	testDocBody := `{"foo":"bar"}`
+	testDoc := NewDocument([]byte(testDocBody))
+	testDoc.Metadata.Set(timestampMetadata, time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+	testDoc.Metadata.Set(strings.ToLower(tid.TransactionIDHeader), testTID)
+
+	params := map[string]string{"id": testKey}
+
+	testCtx := tid.TransactionAwareContext(context.Background(), testTID)
+
+	_, docHash, err := s.service.Write(testCtx, testTable, testKey, testDoc, params, "")
+	require.NoError(s.T(), err)
+
+	patch := func(body []byte) ([]byte, error) {
+		return nil, errors.New("test operation failed")
+	}
+
+	_, _, err = s.service.Patch(testCtx, testTable, testKey, patch, NewDocument(nil), params, "")
+	assert.IsType(s.T(), &PatchError{}, err)
+
+	s.assertExpectedDataInDB(testKey, testKeyColumn, testTable, map[string]string{testDocColumn: testDocBody, hashColumn: docHash})
+}
+
 func (s *ServiceRWTestSuite) assertExpectedDataInDB(key string, keyColumn string, table string, expectedValuePerCol map[string]string) {
 	var actualValues []interface{}
 	var expectedValues []string
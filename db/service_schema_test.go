@@ -137,7 +137,8 @@ func checkSchemaExists(conn *sql.DB, schema string) (bool, error) {
 }
 
 func (s *ServiceSchemaTestSuite) TestPing() {
-	srv := NewService(s.dbConn, false, &config.Config{})
+	srv, err := NewService(s.dbConn, false, &config.Config{})
+	require.NoError(s.T(), err)
 
 	msg, err := srv.Ping()
 	assert.NoError(s.T(), err)
@@ -145,7 +146,8 @@ func (s *ServiceSchemaTestSuite) TestPing() {
 }
 
 func (s *ServiceSchemaTestSuite) TestSchemaCheckWithoutMigrating() {
-	srv := NewService(s.dbConn, false, &config.Config{})
+	srv, err := NewService(s.dbConn, false, &config.Config{})
+	require.NoError(s.T(), err)
 
 	msg, err := srv.SchemaCheck()
 	assert.EqualError(s.T(), err, fmt.Sprintf("migrating database from 0 to %d is required", requiredVersion))
@@ -164,7 +166,8 @@ func (s *ServiceSchemaTestSuite) TestSchemaCheckMigrateWhilstLocked() {
 	defer s.dbAdminConn.Exec("SELECT release_lock(?)", dbLockName)
 
 	// try to migrate but another connection has an exclusive lock
-	srv := NewService(s.dbConn, true, &config.Config{})
+	srv, err := NewService(s.dbConn, true, &config.Config{})
+	require.NoError(s.T(), err)
 
 	msg, err := srv.SchemaCheck()
 	assert.EqualError(s.T(), err, fmt.Sprintf("migrating database from 0 to %d failed", requiredVersion))
@@ -172,9 +175,71 @@ func (s *ServiceSchemaTestSuite) TestSchemaCheckMigrateWhilstLocked() {
 }
 
 func (s *ServiceSchemaTestSuite) TestSchemaMigrate() {
-	srv := NewService(s.dbConn, true, &config.Config{})
+	srv, err := NewService(s.dbConn, true, &config.Config{})
+	require.NoError(s.T(), err)
 
 	msg, err := srv.SchemaCheck()
 	assert.NoError(s.T(), err)
 	assert.Equal(s.T(), fmt.Sprintf("Database schema is at version %d", requiredVersion), msg)
 }
+
+func (s *ServiceSchemaTestSuite) TestCheckTables() {
+	cfg, err := config.ReadConfig("../config.yml")
+	require.NoError(s.T(), err)
+
+	srv, err := NewService(s.dbConn, true, cfg)
+	require.NoError(s.T(), err)
+
+	assert.NoError(s.T(), srv.CheckTables())
+}
+
+func (s *ServiceSchemaTestSuite) TestCheckTablesMismatched() {
+	cfg := &config.Config{Paths: map[string]config.Mapping{
+		"/drafts/content/:id": {
+			Table:      "draft_content",
+			Columns:    map[string]config.Column{"uuid": {Expr: ":id"}, "brand": {Expr: "@.x-brand"}, "body": {Expr: "$"}},
+			PrimaryKey: config.PrimaryKey{"uuid"},
+			History:    true,
+		},
+		"/things/:id": {
+			Table:      "things",
+			Columns:    map[string]config.Column{"uuid": {Expr: ":id"}, "body": {Expr: "$"}},
+			PrimaryKey: config.PrimaryKey{"uuid"},
+		},
+	}}
+
+	srv, err := NewService(s.dbConn, true, cfg)
+	require.NoError(s.T(), err)
+
+	err = srv.CheckTables()
+	require.IsType(s.T(), &config.ValidationError{}, err)
+	assert.Equal(s.T(), []string{
+		"table draft_content has no column brand",
+		"table draft_content_history has no column brand",
+		"table things does not exist",
+	}, err.(*config.ValidationError).Problems)
+}
+
+func (s *ServiceSchemaTestSuite) TestReconfigured() {
+	cfg, err := config.ReadConfig("../config.yml")
+	require.NoError(s.T(), err)
+
+	srv, err := NewService(s.dbConn, true, cfg)
+	require.NoError(s.T(), err)
+
+	mismatched := &config.Config{Paths: map[string]config.Mapping{
+		"/things/:id": {Table: "things", Columns: map[string]config.Column{"uuid": {Expr: ":id"}, "body": {Expr: "$"}}, PrimaryKey: config.PrimaryKey{"uuid"}},
+	}}
+	_, err = srv.Reconfigured(mismatched)
+	assert.IsType(s.T(), &config.ValidationError{}, err)
+
+	reduced, err := config.ReadConfig("../config.yml")
+	require.NoError(s.T(), err)
+	delete(reduced.Paths, "/drafts/content/:id")
+
+	reconfigured, err := srv.Reconfigured(reduced)
+	require.NoError(s.T(), err)
+	assert.NotContains(s.T(), reconfigured.(*AuroraRWService).mappedTables(), "draft_content")
+	assert.Contains(s.T(), reconfigured.(*AuroraRWService).mappedTables(), "draft_annotations")
+	assert.Contains(s.T(), srv.mappedTables(), "draft_content", "the mappings of the original service are unchanged")
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+)
+
+const (
+	// defaultDatetimeFormat matches the _timestamp metadata of a request
+	defaultDatetimeFormat = "2006-01-02T15:04:05.000Z"
+	// mysqlDatetimeFormat is how DATETIME values are read, unless the connection parses times
+	mysqlDatetimeFormat = "2006-01-02 15:04:05.999999"
+)
+
+// columnType converts the values of a column before they are written, and formats them when they are read into response metadata.
+// A column without a type is written and read as it is.
+type columnType struct {
+	name   string
+	format string
+}
+
+func newColumnType(column config.Column) columnType {
+	return columnType{name: column.Type, format: column.Format}
+}
+
+// toDatabase converts the value of a column expression. A missing value is written as NULL, as is an empty string in a column that is not a string.
+// String values are converted as the configuration converts default values, so that a valid default is always written.
+func (c columnType) toDatabase(v interface{}) (interface{}, error) {
+	if c.name == "" || v == nil {
+		return v, nil
+	}
+	if s, ok := v.(string); ok {
+		return config.Column{Type: c.name, Format: c.format}.ParseValue(s)
+	}
+
+	switch c.name {
+	case config.ColumnTypeString:
+		return stringValue(v), nil
+
+	case config.ColumnTypeInt:
+		switch v := v.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("%v is not an integer", v)
+			}
+			return int64(v), nil
+		}
+
+	case config.ColumnTypeBool:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case float64:
+			if v == 0 || v == 1 {
+				return v == 1, nil
+			}
+		}
+
+	case config.ColumnTypeJSON:
+		if by, ok := v.([]byte); ok {
+			if !json.Valid(by) {
+				return nil, fmt.Errorf("the value is not valid JSON")
+			}
+			return string(by), nil
+		}
+		by, err := json.Marshal(v)
+		return string(by), err
+	}
+
+	return nil, fmt.Errorf("%s cannot be converted", stringValue(v))
+}
+
+// fromDatabase formats a value read from the column for the response metadata
+func (c columnType) fromDatabase(s string) string {
+	switch c.name {
+	case config.ColumnTypeBool:
+		switch s {
+		case "1":
+			return "true"
+		case "0":
+			return "false"
+		}
+	case config.ColumnTypeDatetime:
+		if t, err := time.Parse(mysqlDatetimeFormat, s); err == nil {
+			return t.Format(c.outputFormat())
+		}
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t.UTC().Format(c.outputFormat())
+		}
+	}
+	return s
+}
+
+func (c columnType) outputFormat() string {
+	if c.format != "" {
+		return c.format
+	}
+	return defaultDatetimeFormat
+}
+
+// stringValue formats a value of an expression as a string, JSON objects and arrays being marshalled
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	by, _ := json.Marshal(v)
+	return string(by)
+}
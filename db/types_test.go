@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnTypeToDatabase(t *testing.T) {
+	tests := []struct {
+		name     string
+		colType  columnType
+		value    interface{}
+		expected interface{}
+	}{
+		{"untyped", columnType{}, 3.0, 3.0},
+		{"missing", columnType{name: config.ColumnTypeInt}, nil, nil},
+		{"string", columnType{name: config.ColumnTypeString}, "foo", "foo"},
+		{"string from number", columnType{name: config.ColumnTypeString}, 3.5, "3.5"},
+		{"string from object", columnType{name: config.ColumnTypeString}, map[string]interface{}{"foo": "bar"}, `{"foo":"bar"}`},
+		{"empty string", columnType{name: config.ColumnTypeString}, "", ""},
+		{"int from string", columnType{name: config.ColumnTypeInt}, " 42 ", int64(42)},
+		{"int from number", columnType{name: config.ColumnTypeInt}, 42.0, int64(42)},
+		{"int from empty string", columnType{name: config.ColumnTypeInt}, "", nil},
+		{"bool from string", columnType{name: config.ColumnTypeBool}, "true", true},
+		{"bool from bool", columnType{name: config.ColumnTypeBool}, false, false},
+		{"bool from number", columnType{name: config.ColumnTypeBool}, 1.0, true},
+		{"datetime", columnType{name: config.ColumnTypeDatetime}, "2018-01-02T03:04:05.678+01:00", time.Date(2018, 1, 2, 2, 4, 5, 678000000, time.UTC)},
+		{"datetime with format", columnType{name: config.ColumnTypeDatetime, format: "02/01/2006"}, "25/12/2017", time.Date(2017, 12, 25, 0, 0, 0, 0, time.UTC)},
+		{"json from object", columnType{name: config.ColumnTypeJSON}, map[string]interface{}{"foo": "bar"}, `{"foo":"bar"}`},
+		{"json from string", columnType{name: config.ColumnTypeJSON}, "foo", `"foo"`},
+		{"json from document", columnType{name: config.ColumnTypeJSON}, []byte(`[1,2]`), `[1,2]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := test.colType.toDatabase(test.value)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestColumnTypeToDatabaseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		colType columnType
+		value   interface{}
+	}{
+		{"int from text", columnType{name: config.ColumnTypeInt}, "forty-two"},
+		{"int from fraction", columnType{name: config.ColumnTypeInt}, 4.2},
+		{"int from bool", columnType{name: config.ColumnTypeInt}, true},
+		{"bool from text", columnType{name: config.ColumnTypeBool}, "yes please"},
+		{"bool from number", columnType{name: config.ColumnTypeBool}, 2.0},
+		{"datetime in another format", columnType{name: config.ColumnTypeDatetime}, "25/12/2017"},
+		{"datetime from number", columnType{name: config.ColumnTypeDatetime}, 1514764800.0},
+		{"json from invalid document", columnType{name: config.ColumnTypeJSON}, []byte(`not json`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.colType.toDatabase(test.value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestColumnTypeFromDatabase(t *testing.T) {
+	assert.Equal(t, "42", columnType{}.fromDatabase("42"))
+	assert.Equal(t, "true", columnType{name: config.ColumnTypeBool}.fromDatabase("1"))
+	assert.Equal(t, "false", columnType{name: config.ColumnTypeBool}.fromDatabase("0"))
+	assert.Equal(t, "2018-01-02T03:04:05.678Z", columnType{name: config.ColumnTypeDatetime}.fromDatabase("2018-01-02 03:04:05.678"))
+	assert.Equal(t, "2018-01-02T03:04:05.000Z", columnType{name: config.ColumnTypeDatetime}.fromDatabase("2018-01-02 03:04:05"))
+	assert.Equal(t, "02/01/2018", columnType{name: config.ColumnTypeDatetime, format: "02/01/2006"}.fromDatabase("2018-01-02T03:04:05Z"))
+	assert.Equal(t, "not a date", columnType{name: config.ColumnTypeDatetime}.fromDatabase("not a date"))
+}
+
+func TestBuildColumnValuesInvalidValue(t *testing.T) {
+	tables, _, err := mapTables(&config.Config{Paths: map[string]config.Mapping{
+		"/things/:id": {
+			Table: "things",
+			Columns: map[string]config.Column{
+				"uuid":    {Expr: ":id"},
+				"version": {Expr: "$.version", Type: config.ColumnTypeInt},
+				"body":    {Expr: "$"},
+			},
+			PrimaryKey: config.PrimaryKey{"uuid"},
+		},
+	}})
+	require.NoError(t, err)
+
+	values, err := buildColumnValues(context.Background(), tables["things"], "1234", NewDocument([]byte(`{"version":7}`)), map[string]string{"id": "1234"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"", []byte(`{"version":7}`), "1234", int64(7)}, values)
+
+	_, err = buildColumnValues(context.Background(), tables["things"], "1234", NewDocument([]byte(`{"version":"seven"}`)), map[string]string{"id": "1234"})
+	require.IsType(t, &ValueError{}, err)
+	assert.Equal(t, "version", err.(*ValueError).Column)
+}
+
+func TestBuildColumnValuesRequiredAndDefault(t *testing.T) {
+	tables, _, err := mapTables(&config.Config{Paths: map[string]config.Mapping{
+		"/things/:id": {
+			Table: "things",
+			Columns: map[string]config.Column{
+				"uuid":          {Expr: ":id"},
+				"origin_system": {Expr: "@.x-origin-system-id", Required: true},
+				"type":          {Expr: "$.type", Default: "Article"},
+				"version":       {Expr: "$.version", Type: config.ColumnTypeInt, Required: true},
+				"body":          {Expr: "$", Required: true},
+			},
+			PrimaryKey: config.PrimaryKey{"uuid"},
+		},
+	}})
+	require.NoError(t, err)
+
+	doc := NewDocument([]byte(`{"version":7}`))
+	doc.Metadata.Set("x-origin-system-id", "cct")
+	values, err := buildColumnValues(context.Background(), tables["things"], "1234", doc, map[string]string{"id": "1234"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"", []byte(`{"version":7}`), "cct", "Article", "1234", int64(7)}, values)
+
+	_, err = buildColumnValues(context.Background(), tables["things"], "1234", NewDocument([]byte(`{"type":"Video"}`)), map[string]string{"id": "1234"})
+	require.IsType(t, &MissingValuesError{}, err)
+	assert.EqualError(t, err, "missing required value(s) for column(s): origin_system (@.x-origin-system-id), version ($.version)")
+}
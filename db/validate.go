@@ -0,0 +1,77 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+)
+
+const tableColumnsSql = "SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ?"
+
+// CheckTables checks every configured table, and its history table if history is enabled, against the database schema.
+// It returns a *config.ValidationError listing the missing tables and columns, if any.
+func (service *AuroraRWService) CheckTables() error {
+	tables := service.mappedTables()
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		t := tables[name]
+
+		tableProblems, err := service.checkTableColumns(t.name, t.storedColumns())
+		if err != nil {
+			return err
+		}
+		problems = append(problems, tableProblems...)
+
+		if t.history {
+			tableProblems, err = service.checkTableColumns(t.historyTable(), append(t.storedColumns(), historyIdColumn))
+			if err != nil {
+				return err
+			}
+			problems = append(problems, tableProblems...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &config.ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (service *AuroraRWService) checkTableColumns(tableName string, required []string) ([]string, error) {
+	rows, err := service.conn.Query(tableColumnsSql, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var col string
+		if err = rows.Scan(&col); err != nil {
+			return nil, err
+		}
+		columns[col] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return []string{fmt.Sprintf("table %s does not exist", tableName)}, nil
+	}
+
+	var problems []string
+	for _, col := range required {
+		if !columns[col] {
+			problems = append(problems, fmt.Sprintf("table %s has no column %s", tableName, col))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,172 @@
+// Package expression parses and evaluates the expressions that map requests to column values.
+//
+// An expression is one of:
+//
+//	:name            a request parameter, e.g. :id or :query.brand
+//	@.name           a metadata value, e.g. @.x-origin-system-id
+//	$                the whole document
+//	$.path           a JSONPath in the document, e.g. $.post.body
+//	fn(arg, ...)     a function call, whose arguments are expressions, "quoted strings" or numbers
+//
+// Anything else is a literal value.
+package expression
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/oliveagle/jsonpath"
+)
+
+// valueType is the type of the values of an expression, checked when it is parsed
+type valueType int
+
+const (
+	// typeString values are strings
+	typeString valueType = iota
+	// typeAny values are JSON values, or nil
+	typeAny
+	// typeDocument values are the bytes of the whole document
+	typeDocument
+)
+
+// Input is what an expression is evaluated against
+type Input struct {
+	Params   map[string]string
+	Metadata map[string]string
+	Body     []byte
+
+	// the document is only unmarshalled if necessary, and only once
+	jsonDoc    interface{}
+	jsonParsed bool
+}
+
+func (in *Input) jsonDocument() interface{} {
+	if !in.jsonParsed {
+		json.Unmarshal(in.Body, &in.jsonDoc)
+		in.jsonParsed = true
+	}
+	return in.jsonDoc
+}
+
+// Expression is a parsed column expression
+type Expression interface {
+	// Eval returns the value of the expression. An error is returned with a nil value when the value cannot be found, e.g. a JSONPath that is not in the document.
+	Eval(in *Input) (interface{}, error)
+	// String returns the expression as it was configured
+	String() string
+	valueType() valueType
+}
+
+type literal struct {
+	value string
+	src   string
+}
+
+func (e literal) Eval(in *Input) (interface{}, error) { return e.value, nil }
+func (e literal) String() string                      { return e.src }
+func (e literal) valueType() valueType                { return typeString }
+
+type param struct {
+	name string
+}
+
+func (e param) Eval(in *Input) (interface{}, error) { return in.Params[e.name], nil }
+func (e param) String() string                      { return ":" + e.name }
+func (e param) valueType() valueType                { return typeString }
+
+type metadata struct {
+	name string
+}
+
+func (e metadata) Eval(in *Input) (interface{}, error) { return in.Metadata[e.name], nil }
+func (e metadata) String() string                      { return "@." + e.name }
+func (e metadata) valueType() valueType                { return typeString }
+
+type document struct{}
+
+func (e document) Eval(in *Input) (interface{}, error) { return in.Body, nil }
+func (e document) String() string                      { return "$" }
+func (e document) valueType() valueType                { return typeDocument }
+
+type jsonPath struct {
+	path string
+}
+
+func (e jsonPath) Eval(in *Input) (interface{}, error) {
+	return jsonpath.JsonPathLookup(in.jsonDocument(), e.path)
+}
+func (e jsonPath) String() string       { return e.path }
+func (e jsonPath) valueType() valueType { return typeAny }
+
+type call struct {
+	fn   function
+	name string
+	args []Expression
+}
+
+func (e call) Eval(in *Input) (interface{}, error) {
+	return e.fn.eval(in, e.args)
+}
+
+func (e call) String() string {
+	s := e.name + "("
+	for i, arg := range e.args {
+		if i > 0 {
+			s += ", "
+		}
+		if lit, ok := arg.(literal); ok {
+			s += lit.src
+		} else {
+			s += arg.String()
+		}
+	}
+	return s + ")"
+}
+
+func (e call) valueType() valueType { return e.fn.result }
+
+// IsDocument returns whether the expression is the whole document ($)
+func IsDocument(e Expression) bool {
+	_, ok := e.(document)
+	return ok
+}
+
+// MetadataName returns the name of the metadata value, if the expression is a metadata value (@.name)
+func MetadataName(e Expression) (string, bool) {
+	m, ok := e.(metadata)
+	return m.name, ok
+}
+
+// Params returns the names of the request parameters the expression refers to, in order of appearance
+func Params(e Expression) []string {
+	switch e := e.(type) {
+	case param:
+		return []string{e.name}
+	case call:
+		var params []string
+		for _, arg := range e.args {
+			params = append(params, Params(arg)...)
+		}
+		return params
+	}
+	return nil
+}
+
+// toString converts a value to the string used by functions such as lower() and concat()
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	by, _ := json.Marshal(v)
+	return string(by)
+}
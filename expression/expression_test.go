@@ -0,0 +1,117 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testInput() *Input {
+	return &Input{
+		Params:   map[string]string{"id": "1234", "query.brand": "FT"},
+		Metadata: map[string]string{"x-origin-system-id": "Methode", "_timestamp": "2017-10-27T10:00:00.000Z"},
+		Body:     []byte(`{"type":"Article","version":3,"post":{"body":"text"},"empty":""}`),
+	}
+}
+
+func TestEval(t *testing.T) {
+	clock = func() time.Time { return time.Date(2018, 1, 2, 3, 4, 5, 6000000, time.UTC) }
+	defer func() { clock = time.Now }()
+
+	tests := []struct {
+		expr     string
+		expected interface{}
+	}{
+		{":id", "1234"},
+		{":query.brand", "FT"},
+		{"@.x-origin-system-id", "Methode"},
+		{"$", []byte(`{"type":"Article","version":3,"post":{"body":"text"},"empty":""}`)},
+		{"$.post.body", "text"},
+		{"cct", "cct"},
+		{"a literal with spaces, commas and (parentheses)", "a literal with spaces, commas and (parentheses)"},
+		{"now()", "2018-01-02T03:04:05.006Z"},
+		{"lower(@.x-origin-system-id)", "methode"},
+		{"upper( :query.brand )", "FT"},
+		{`coalesce($.subtype, "unknown")`, "unknown"},
+		{`coalesce($.empty, $.type)`, "Article"},
+		{`coalesce($.missing, :missing)`, nil},
+		{`concat(:id, "-", $.version)`, "1234-3"},
+		{`concat("say \"hi\"")`, `say "hi"`},
+		{`concat(lower($.type), 42)`, "article42"},
+		{"hash($)", "f7d8810ff78a1107774c2d0a6b57453b334fcc984c4dc2041164efa5"},
+		{"hash($.post.body)", "165543bb2301d8334d4a1a3a24508ecdb815a4d2f69ab8c6cdb19ae5"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			e, err := Parse(test.expr)
+			require.NoError(t, err)
+			actual, err := e.Eval(testInput())
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestEvalMissingJSONPath(t *testing.T) {
+	e, err := Parse("$.missing")
+	require.NoError(t, err)
+	actual, err := e.Eval(testInput())
+	assert.Error(t, err)
+	assert.Nil(t, actual)
+}
+
+func TestEvalUUID(t *testing.T) {
+	e, err := Parse("uuid()")
+	require.NoError(t, err)
+	first, _ := e.Eval(testInput())
+	second, _ := e.Eval(testInput())
+	assert.Len(t, first, 36)
+	assert.NotEqual(t, first, second)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"upcase(:id)", "unknown function upcase() at position 1"},
+		{"lower()", "lower() takes 1 argument(s), not 0 at position 1"},
+		{"lower(:id, :id)", "lower() takes 1 argument(s), not 2 at position 1"},
+		{"coalesce($.type)", "coalesce() takes at least 2 argument(s), not 1 at position 1"},
+		{"now(:id)", "now() takes 0 argument(s), not 1 at position 1"},
+		{"lower($)", "the whole document ($) cannot be an argument of lower() at position 8"},
+		{"concat(:id", "missing ) after arguments of concat() at position 11"},
+		{"concat(:id bar)", `unexpected "bar)" in arguments of concat() at position 12`},
+		{"concat(:, :id)", "missing parameter name after : at position 9"},
+		{`concat("abc)`, "unterminated string at position 8"},
+		{"lower(:id) extra", `unexpected "extra" at position 12`},
+		{"lower(bare)", `unexpected "bare)" at position 7`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := Parse(test.expr)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	e, _ := Parse("$")
+	assert.True(t, IsDocument(e))
+
+	e, _ = Parse("@.x-origin-system-id")
+	name, ok := MetadataName(e)
+	assert.True(t, ok)
+	assert.Equal(t, "x-origin-system-id", name)
+
+	e, _ = Parse(`concat(:id, "-", lower(:query.brand))`)
+	assert.False(t, IsDocument(e))
+	_, ok = MetadataName(e)
+	assert.False(t, ok)
+	assert.Equal(t, []string{"id", "query.brand"}, Params(e))
+	assert.Equal(t, `concat(:id, "-", lower(:query.brand))`, e.String())
+}
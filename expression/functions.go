@@ -0,0 +1,72 @@
+package expression
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+// timestampFormat is the format of now(), which matches the _timestamp metadata of a request
+const timestampFormat = "2006-01-02T15:04:05.000Z"
+
+// clock returns the current time, and is replaced in tests
+var clock = time.Now
+
+type function struct {
+	minArgs int
+	// maxArgs is -1 for any number of arguments
+	maxArgs int
+	// acceptsDocument is set if the whole document ($) may be an argument
+	acceptsDocument bool
+	result          valueType
+	eval            func(in *Input, args []Expression) (interface{}, error)
+}
+
+var functions = map[string]function{
+	"now": {0, 0, false, typeString, func(in *Input, args []Expression) (interface{}, error) {
+		return clock().UTC().Format(timestampFormat), nil
+	}},
+	"uuid": {0, 0, false, typeString, func(in *Input, args []Expression) (interface{}, error) {
+		return uuid.NewV4().String(), nil
+	}},
+	"lower": {1, 1, false, typeString, func(in *Input, args []Expression) (interface{}, error) {
+		v, err := args[0].Eval(in)
+		return strings.ToLower(toString(v)), err
+	}},
+	"upper": {1, 1, false, typeString, func(in *Input, args []Expression) (interface{}, error) {
+		v, err := args[0].Eval(in)
+		return strings.ToUpper(toString(v)), err
+	}},
+	"coalesce": {2, -1, false, typeAny, func(in *Input, args []Expression) (interface{}, error) {
+		// a value that cannot be found is skipped, like an empty one
+		for _, arg := range args {
+			if v, err := arg.Eval(in); err == nil && v != nil && v != "" {
+				return v, nil
+			}
+		}
+		return nil, nil
+	}},
+	"concat": {1, -1, false, typeString, func(in *Input, args []Expression) (interface{}, error) {
+		var sb strings.Builder
+		var firstErr error
+		for _, arg := range args {
+			v, err := arg.Eval(in)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			sb.WriteString(toString(v))
+		}
+		return sb.String(), firstErr
+	}},
+	"hash": {1, 1, true, typeString, func(in *Input, args []Expression) (interface{}, error) {
+		v, err := args[0].Eval(in)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum224([]byte(toString(v)))
+		return hex.EncodeToString(sum[:]), nil
+	}},
+}
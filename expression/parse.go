@@ -0,0 +1,219 @@
+package expression
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	callPattern   = regexp.MustCompile(`^[a-z_]+\s*\(`)
+	numberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?`)
+)
+
+// Parse parses and type-checks a column expression
+func Parse(src string) (Expression, error) {
+	switch {
+	case strings.HasPrefix(src, ":"):
+		// a reference on its own runs to the end of the expression, as it always has
+		return param{src[1:]}, nil
+	case strings.HasPrefix(src, "@."):
+		return metadata{src[2:]}, nil
+	case src == "$":
+		return document{}, nil
+	case strings.HasPrefix(src, "$"):
+		return jsonPath{src}, nil
+	case !callPattern.MatchString(src):
+		return literal{src, src}, nil
+	}
+
+	p := &parser{src: src}
+	e, err := p.parseCall()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return e, nil
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), p.pos+1)
+}
+
+// parseArg parses a function argument: a reference, a function call, a quoted string or a number
+func (p *parser) parseArg() (Expression, error) {
+	p.skipSpace()
+	rest := p.src[p.pos:]
+	switch {
+	case p.done():
+		return nil, p.errorf("unexpected end of expression")
+	case strings.HasPrefix(rest, ":"):
+		p.pos++
+		name := p.scanName()
+		if name == "" {
+			return nil, p.errorf("missing parameter name after :")
+		}
+		return param{name}, nil
+	case strings.HasPrefix(rest, "@."):
+		p.pos += 2
+		name := p.scanName()
+		if name == "" {
+			return nil, p.errorf("missing metadata name after @.")
+		}
+		return metadata{name}, nil
+	case strings.HasPrefix(rest, "$"):
+		path := p.scanPath()
+		if path == "$" {
+			return document{}, nil
+		}
+		return jsonPath{path}, nil
+	case rest[0] == '"':
+		return p.parseString()
+	case numberPattern.MatchString(rest):
+		n := numberPattern.FindString(rest)
+		p.pos += len(n)
+		return literal{n, n}, nil
+	case callPattern.MatchString(rest):
+		return p.parseCall()
+	}
+	return nil, p.errorf("unexpected %q", rest)
+}
+
+func (p *parser) parseCall() (Expression, error) {
+	start := p.pos
+	name := strings.TrimSpace(p.src[p.pos : p.pos+strings.IndexByte(p.src[p.pos:], '(')])
+	fn, found := functions[name]
+	if !found {
+		return nil, p.errorf("unknown function %s()", name)
+	}
+	p.pos += strings.IndexByte(p.src[p.pos:], '(') + 1
+
+	var args []Expression
+	p.skipSpace()
+	if p.peek() == ')' {
+		p.pos++
+	} else {
+		for {
+			arg, err := p.parseArg()
+			if err != nil {
+				return nil, err
+			}
+			if arg.valueType() == typeDocument && !fn.acceptsDocument {
+				return nil, p.errorf("the whole document ($) cannot be an argument of %s()", name)
+			}
+			args = append(args, arg)
+
+			p.skipSpace()
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			if p.peek() == ')' {
+				p.pos++
+				break
+			}
+			if p.done() {
+				return nil, p.errorf("missing ) after arguments of %s()", name)
+			}
+			return nil, p.errorf("unexpected %q in arguments of %s()", p.src[p.pos:], name)
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		p.pos = start
+		return nil, p.errorf("%s() %s, not %d", name, arity(fn), len(args))
+	}
+	return call{fn, name, args}, nil
+}
+
+func arity(fn function) string {
+	switch {
+	case fn.maxArgs < 0:
+		return fmt.Sprintf("takes at least %d argument(s)", fn.minArgs)
+	case fn.minArgs == fn.maxArgs:
+		return fmt.Sprintf("takes %d argument(s)", fn.minArgs)
+	}
+	return fmt.Sprintf("takes %d to %d arguments", fn.minArgs, fn.maxArgs)
+}
+
+// scanName returns the name of a parameter or metadata value
+func (p *parser) scanName() string {
+	start := p.pos
+	for !p.done() {
+		c := p.peek()
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.') {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// scanPath returns a JSONPath, which ends at a comma, a closing parenthesis or a space outside of brackets
+func (p *parser) scanPath() string {
+	start := p.pos
+	depth := 0
+	for !p.done() {
+		c := p.peek()
+		if depth == 0 && (c == ',' || c == ')' || c == ' ' || c == '\t') {
+			break
+		}
+		if c == '[' || c == '(' {
+			depth++
+		} else if (c == ']' || c == ')') && depth > 0 {
+			depth--
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// parseString parses a double-quoted string, in which \" and \\ are escaped
+func (p *parser) parseString() (Expression, error) {
+	start := p.pos
+	p.pos++
+	var sb strings.Builder
+	for !p.done() {
+		c := p.peek()
+		p.pos++
+		switch c {
+		case '\\':
+			if p.done() {
+				return nil, p.errorf("unterminated string")
+			}
+			sb.WriteByte(p.peek())
+			p.pos++
+		case '"':
+			return literal{sb.String(), p.src[start:p.pos]}, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	p.pos = start
+	return nil, p.errorf("unterminated string")
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/Financial-Times/generic-rw-aurora/config"
 	"github.com/Financial-Times/generic-rw-aurora/db"
 	"github.com/Financial-Times/generic-rw-aurora/health"
-	"github.com/Financial-Times/generic-rw-aurora/resources"
 	"github.com/Financial-Times/http-handlers-go/httphandlers"
 	status "github.com/Financial-Times/service-status-go/httphandlers"
 	"github.com/husobee/vestigo"
@@ -72,10 +71,24 @@ func main() {
 	rwYml := app.String(cli.StringOpt{
 		Name:   "rw-config",
 		Value:  "./config.yml",
-		Desc:   "Location of the RW configuration YML file.",
+		Desc:   "Location of the RW configuration: a YML file, a directory of YML files or a glob pattern.",
 		EnvVar: "RW_CONFIG",
 	})
 
+	rwConfigPollInterval := app.String(cli.StringOpt{
+		Name:   "rw-config-poll-interval",
+		Value:  "30s",
+		Desc:   "Interval at which the RW configuration YML file is checked for changes, or 0 to only reload it on request",
+		EnvVar: "RW_CONFIG_POLL_INTERVAL",
+	})
+
+	maxBodySize := app.String(cli.StringOpt{
+		Name:   "max-body-size",
+		Value:  "16MB",
+		Desc:   "Default limit of the size of write request bodies, e.g. 512KB or 16MB, unless a path of the RW configuration sets its own maxBodySize",
+		EnvVar: "MAX_BODY_SIZE",
+	})
+
 	apiYml := app.String(cli.StringOpt{
 		Name:   "api-yml",
 		Value:  "./api.yml",
@@ -94,13 +107,26 @@ func main() {
 		if err != nil {
 			log.WithError(err).Fatal("unable to read r/w YAML configuration")
 		}
+		if err = rwConfig.Validate(); err != nil {
+			log.WithError(err).Fatal("invalid r/w YAML configuration")
+		}
 
 		conn, err := db.Connect(*dbURL, maxConnections)
 		if err != nil {
 			log.WithError(err).Error("unable to connect to database")
 		}
 
-		rw := db.NewService(conn, *performSchemaMigrations, rwConfig)
+		rw, err := db.NewService(conn, *performSchemaMigrations, rwConfig)
+		if err != nil {
+			log.WithError(err).Fatal("unable to map r/w YAML configuration")
+		}
+
+		// the tables can only be checked once the database is reachable and its schema is up to date
+		if _, err = rw.SchemaCheck(); err == nil {
+			if err = rw.CheckTables(); err != nil {
+				log.WithError(err).Fatal("r/w YAML configuration does not match the database schema")
+			}
+		}
 
 		healthService := health.NewHealthService(*appSystemCode, *appName, appDescription, rw)
 
@@ -110,44 +136,76 @@ func main() {
 			log.WithError(err).Error("unable to parse timeout")
 			return
 		}
-		serveEndpoints(*port, apiYml, rwConfig, rw, healthService, timeout)
+
+		pollInterval, err := time.ParseDuration(*rwConfigPollInterval)
+		if err != nil {
+			log.WithError(err).Error("unable to parse r/w configuration poll interval")
+			return
+		}
+
+		bodySizeLimit, err := config.ParseByteSize(*maxBodySize)
+		if err != nil {
+			log.WithError(err).Error("unable to parse max body size")
+			return
+		}
+
+		reloader := newConfigReloader(*rwYml, rw, timeout, bodySizeLimit, monitoringRoutes(healthService, apiYml))
+		reloader.install(rwConfig, rw)
+		if pollInterval > 0 {
+			go reloader.watch(pollInterval)
+		}
+		serveEndpoints(*port, reloader)
 	}
 
+	app.Command("validate-config", "Validate the RW configuration YML file, without connecting to the database", func(cmd *cli.Cmd) {
+		cmd.Action = func() {
+			exitOnError(validateConfig(os.Stdout, *rwYml))
+		}
+	})
+
+	app.Command("print-routes", "Print the endpoints, SQL statements and response headers for each path of the RW configuration, without connecting to the database", func(cmd *cli.Cmd) {
+		cmd.Action = func() {
+			log.SetLevel(log.WarnLevel)
+			exitOnError(printRoutes(os.Stdout, *rwYml))
+		}
+	})
+
 	err := app.Run(os.Args)
 	if err != nil {
 		log.WithError(err).Error("App could not start")
 	}
 }
 
-func serveEndpoints(port string, apiYml *string, rw *config.Config, db db.RWService, healthService *health.HealthService, timeout time.Duration) {
-	r := vestigo.NewRouter()
-
-	var monitoringRouter http.Handler = r
+func serveEndpoints(port string, reloader *configReloader) {
+	var monitoringRouter http.Handler = reloader
 	monitoringRouter = httphandlers.TransactionAwareRequestLoggingHandler(log.StandardLogger(), monitoringRouter)
 	monitoringRouter = httphandlers.HTTPMetricsHandler(metrics.DefaultRegistry, monitoringRouter)
 
-	r.Get("/__health", healthService.HealthCheckHandleFunc())
-	r.Get(status.GTGPath, status.NewGoodToGoHandler(healthService.GTG))
-	r.Get(status.BuildInfoPath, status.BuildInfoHandler)
+	http.Handle("/", monitoringRouter)
 
-	for path, cfg := range rw.Paths {
-		r.Get(path, resources.Read(db, cfg.Table, timeout))
-		r.Put(path, resources.Write(db, cfg.Table, timeout))
-		log.WithField("path", path).WithField("table", cfg.Table).Info("added r/w endpoint")
+	if err := http.ListenAndServe(":"+port, nil); err != nil {
+		log.Fatalf("Unable to start: %v", err)
 	}
+}
 
-	http.Handle("/", monitoringRouter)
-
+// monitoringRoutes returns a function adding the endpoints that do not depend on the r/w configuration to a router
+func monitoringRoutes(healthService *health.HealthService, apiYml *string) func(r *vestigo.Router) {
+	var apiEndpoint api.Endpoint
 	if apiYml != nil {
-		apiEndpoint, err := api.NewAPIEndpointForFile(*apiYml)
+		endpoint, err := api.NewAPIEndpointForFile(*apiYml)
 		if err != nil {
 			log.WithError(err).WithField("file", *apiYml).Warn("Failed to serve the API Endpoint for this service. Please validate the Swagger YML and the file location")
 		} else {
-			r.Get(api.DefaultPath, apiEndpoint.ServeHTTP)
+			apiEndpoint = endpoint
 		}
 	}
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
-		log.Fatalf("Unable to start: %v", err)
+	return func(r *vestigo.Router) {
+		r.Get("/__health", healthService.HealthCheckHandleFunc())
+		r.Get(status.GTGPath, status.NewGoodToGoHandler(healthService.GTG))
+		r.Get(status.BuildInfoPath, status.BuildInfoHandler)
+		if apiEndpoint != nil {
+			r.Get(api.DefaultPath, apiEndpoint.ServeHTTP)
+		}
 	}
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/Financial-Times/generic-rw-aurora/resources"
+	"github.com/husobee/vestigo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	configPath       = "/__config"
+	configReloadPath = "/__config/reload"
+)
+
+// reconfigurableService is a r/w service that can create a service for the table mappings of another configuration
+type reconfigurableService interface {
+	db.RWService
+	Reconfigured(rwConfig *config.Config) (db.RWService, error)
+}
+
+// configReloader routes requests to the endpoints of the active r/w configuration, and replaces them when the configuration is reloaded
+type configReloader struct {
+	rwYml       string
+	rw          reconfigurableService
+	timeout     time.Duration
+	maxBodySize config.ByteSize
+	baseRoutes  func(r *vestigo.Router)
+	reloadLock  sync.Mutex
+	snapshot    atomic.Value
+}
+
+// configSnapshot is the router for the endpoints of a configuration, whose handlers use a service with the table mappings of the same configuration.
+// A snapshot is replaced as a whole, so that a request is never routed by one configuration and mapped by another.
+type configSnapshot struct {
+	router http.Handler
+	active activeConfig
+}
+
+// activeConfig describes the r/w configuration currently served
+type activeConfig struct {
+	Version  string    `json:"version"`
+	LoadedAt time.Time `json:"loadedAt"`
+	Paths    []string  `json:"paths"`
+}
+
+type reloadResponse struct {
+	activeConfig
+	Reloaded bool `json:"reloaded"`
+}
+
+func newConfigReloader(rwYml string, rw reconfigurableService, timeout time.Duration, maxBodySize config.ByteSize, baseRoutes func(r *vestigo.Router)) *configReloader {
+	return &configReloader{rwYml: rwYml, rw: rw, timeout: timeout, maxBodySize: maxBodySize, baseRoutes: baseRoutes}
+}
+
+func (c *configReloader) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	c.snapshot.Load().(configSnapshot).router.ServeHTTP(writer, request)
+}
+
+func (c *configReloader) activeConfig() activeConfig {
+	return c.snapshot.Load().(configSnapshot).active
+}
+
+// install swaps in a router for the endpoints of the configuration, whose handlers use a service configured with the same configuration
+func (c *configReloader) install(rwConfig *config.Config, rw db.RWService) {
+	r := vestigo.NewRouter()
+	c.baseRoutes(r)
+	r.Get(configPath, c.configHandler)
+	r.Post(configReloadPath, c.reloadHandler)
+
+	active := activeConfig{Version: rwConfig.Version(), LoadedAt: time.Now().UTC()}
+	for path, cfg := range rwConfig.Paths {
+		for _, route := range pathRoutes(path, cfg, rw, c.timeout, c.maxBodySize) {
+			r.Add(route.method, route.path, route.handler)
+			log.WithFields(log.Fields{"method": route.method, "path": route.path, "table": cfg.Table}).Info("added endpoint")
+		}
+		active.Paths = append(active.Paths, path)
+	}
+	sort.Strings(active.Paths)
+
+	c.snapshot.Store(configSnapshot{router: resources.StripRouteParams(r), active: active})
+	log.WithField("version", active.Version).Info("r/w configuration installed")
+}
+
+// reload reads and validates the configuration file, and applies it if it differs from the active configuration.
+// The active configuration is kept if the file cannot be read, is not valid or does not match the database.
+func (c *configReloader) reload() (activeConfig, bool, error) {
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	rwConfig, err := config.ReadConfig(c.rwYml)
+	if err != nil {
+		return c.activeConfig(), false, err
+	}
+	if err = rwConfig.Validate(); err != nil {
+		return c.activeConfig(), false, err
+	}
+
+	if rwConfig.Version() == c.activeConfig().Version {
+		return c.activeConfig(), false, nil
+	}
+
+	rw, err := c.rw.Reconfigured(rwConfig)
+	if err != nil {
+		return c.activeConfig(), false, err
+	}
+	c.install(rwConfig, rw)
+	return c.activeConfig(), true, nil
+}
+
+// watch reloads the configuration file at every interval, until the process exits
+func (c *configReloader) watch(interval time.Duration) {
+	var lastErr string
+	for range time.Tick(interval) {
+		_, _, err := c.reload()
+		if err == nil {
+			lastErr = ""
+			continue
+		}
+		// a broken file is reported once, rather than at every interval
+		if err.Error() != lastErr {
+			log.WithError(err).WithField("file", c.rwYml).Error("unable to reload r/w YAML configuration, keeping the active configuration")
+			lastErr = err.Error()
+		}
+	}
+}
+
+func (c *configReloader) configHandler(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(c.activeConfig())
+}
+
+func (c *configReloader) reloadHandler(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+
+	active, reloaded, err := c.reload()
+	if err != nil {
+		log.WithError(err).WithField("file", c.rwYml).Error("unable to reload r/w YAML configuration, keeping the active configuration")
+		body := map[string]interface{}{"message": err.Error()}
+		if validationErr, ok := err.(*config.ValidationError); ok {
+			body["problems"] = validationErr.Problems
+			writer.WriteHeader(http.StatusUnprocessableEntity)
+		} else {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(writer).Encode(body)
+		return
+	}
+
+	json.NewEncoder(writer).Encode(reloadResponse{active, reloaded})
+}
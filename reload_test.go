@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reloadTestConfig = `paths:
+  "/things/:id":
+    table: things
+    columns:
+      uuid: ":id"
+      body: "$"
+    primaryKey: uuid
+`
+
+const reloadTestConfigWithWidgets = reloadTestConfig + `  "/widgets/:id":
+    table: widgets
+    columns:
+      uuid: ":id"
+      body: "$"
+    primaryKey: uuid
+`
+
+type fakeReconfigurableService struct {
+	db.RWService
+	reconfigured []*config.Config
+	next         db.RWService
+	err          error
+}
+
+func (s *fakeReconfigurableService) Reconfigured(rwConfig *config.Config) (db.RWService, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.reconfigured = append(s.reconfigured, rwConfig)
+	if s.next != nil {
+		return s.next, nil
+	}
+	return s, nil
+}
+
+// fakeReadService reads the same document from every table, recording the tables read
+type fakeReadService struct {
+	db.RWService
+	tables []string
+}
+
+func (s *fakeReadService) Read(ctx context.Context, table string, key string) (db.Document, error) {
+	s.tables = append(s.tables, table)
+	return db.NewDocument([]byte(`{"uuid":"` + key + `"}`)), nil
+}
+
+func writeTestConfig(t *testing.T, yml string) string {
+	f, err := ioutil.TempFile("", "config-*.yml")
+	require.NoError(t, err)
+	_, err = f.WriteString(yml)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return f.Name()
+}
+
+func newTestReloader(t *testing.T, rwYml string, rw reconfigurableService) *configReloader {
+	rwConfig, err := config.ReadConfig(rwYml)
+	require.NoError(t, err)
+
+	reloader := newConfigReloader(rwYml, rw, time.Second, 0, func(r *vestigo.Router) {
+		r.Get("/__gtg", func(writer http.ResponseWriter, request *http.Request) {})
+	})
+	reloader.install(rwConfig, rw)
+	return reloader
+}
+
+func serve(reloader *configReloader, method string, path string) *http.Response {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	reloader.ServeHTTP(w, req)
+	return w.Result()
+}
+
+func TestConfigStatus(t *testing.T) {
+	rwYml := writeTestConfig(t, reloadTestConfig)
+	defer os.Remove(rwYml)
+
+	reloader := newTestReloader(t, rwYml, &fakeReconfigurableService{})
+
+	actual := serve(reloader, "GET", "/__config")
+	assert.Equal(t, http.StatusOK, actual.StatusCode)
+
+	var body activeConfig
+	require.NoError(t, json.NewDecoder(actual.Body).Decode(&body))
+	assert.Len(t, body.Version, 12)
+	assert.Equal(t, []string{"/things/:id"}, body.Paths)
+	assert.Equal(t, http.StatusOK, serve(reloader, "GET", "/__gtg").StatusCode, "base routes are installed")
+}
+
+func TestReload(t *testing.T) {
+	rwYml := writeTestConfig(t, reloadTestConfig)
+	defer os.Remove(rwYml)
+
+	rw := &fakeReconfigurableService{}
+	reloader := newTestReloader(t, rwYml, rw)
+	initialVersion := reloader.activeConfig().Version
+
+	assert.Equal(t, http.StatusNotFound, serve(reloader, "GET", "/widgets/1234").StatusCode, "widgets are not routed before the reload")
+
+	require.NoError(t, ioutil.WriteFile(rwYml, []byte(reloadTestConfigWithWidgets), 0644))
+
+	actual := serve(reloader, "POST", "/__config/reload")
+	assert.Equal(t, http.StatusOK, actual.StatusCode)
+
+	var body reloadResponse
+	require.NoError(t, json.NewDecoder(actual.Body).Decode(&body))
+	assert.True(t, body.Reloaded)
+	assert.NotEqual(t, initialVersion, body.Version)
+	assert.Equal(t, []string{"/things/:id", "/widgets/:id"}, body.Paths)
+
+	require.Len(t, rw.reconfigured, 1)
+	assert.Contains(t, rw.reconfigured[0].Paths, "/widgets/:id")
+	assert.Equal(t, body.Version, reloader.activeConfig().Version)
+}
+
+func TestReloadUnchanged(t *testing.T) {
+	rwYml := writeTestConfig(t, reloadTestConfig)
+	defer os.Remove(rwYml)
+
+	rw := &fakeReconfigurableService{}
+	reloader := newTestReloader(t, rwYml, rw)
+
+	active, reloaded, err := reloader.reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+	assert.Equal(t, []string{"/things/:id"}, active.Paths)
+	assert.Empty(t, rw.reconfigured)
+}
+
+func TestReloadInvalidConfig(t *testing.T) {
+	rwYml := writeTestConfig(t, reloadTestConfig)
+	defer os.Remove(rwYml)
+
+	rw := &fakeReconfigurableService{}
+	reloader := newTestReloader(t, rwYml, rw)
+	initialVersion := reloader.activeConfig().Version
+
+	require.NoError(t, ioutil.WriteFile(rwYml, []byte(`paths:
+  "/widgets/:id":
+    table: widgets
+    primaryKey: uuid
+`), 0644))
+
+	actual := serve(reloader, "POST", "/__config/reload")
+	assert.Equal(t, http.StatusUnprocessableEntity, actual.StatusCode)
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(actual.Body).Decode(&body))
+	assert.Equal(t, []interface{}{
+		"path /widgets/:id: no document column ($) is configured",
+		"path /widgets/:id: primaryKey uuid is not a configured column",
+	}, body["problems"])
+
+	assert.Empty(t, rw.reconfigured)
+	assert.Equal(t, initialVersion, reloader.activeConfig().Version)
+}
+
+func TestReloadRejectedByService(t *testing.T) {
+	rwYml := writeTestConfig(t, reloadTestConfig)
+	defer os.Remove(rwYml)
+
+	rw := &fakeReconfigurableService{}
+	reloader := newTestReloader(t, rwYml, rw)
+	initialVersion := reloader.activeConfig().Version
+
+	require.NoError(t, ioutil.WriteFile(rwYml, []byte(reloadTestConfigWithWidgets), 0644))
+	rw.err = errors.New("test error")
+
+	actual := serve(reloader, "POST", "/__config/reload")
+	assert.Equal(t, http.StatusInternalServerError, actual.StatusCode)
+	assert.Equal(t, initialVersion, reloader.activeConfig().Version)
+}
+
+func TestReloadRoutesToReconfiguredService(t *testing.T) {
+	rwYml := writeTestConfig(t, reloadTestConfig)
+	defer os.Remove(rwYml)
+
+	initial := &fakeReadService{}
+	reconfigured := &fakeReadService{}
+	rw := &fakeReconfigurableService{RWService: initial, next: reconfigured}
+	reloader := newTestReloader(t, rwYml, rw)
+
+	assert.Equal(t, http.StatusOK, serve(reloader, "GET", "/things/1234").StatusCode)
+
+	require.NoError(t, ioutil.WriteFile(rwYml, []byte(reloadTestConfigWithWidgets), 0644))
+	_, reloaded, err := reloader.reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	assert.Equal(t, http.StatusOK, serve(reloader, "GET", "/widgets/1234").StatusCode)
+	assert.Equal(t, []string{"things"}, initial.tables, "the initial service only serves the initial routes")
+	assert.Equal(t, []string{"widgets"}, reconfigured.tables, "the routes of the reloaded configuration use the reconfigured service")
+}
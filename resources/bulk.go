@@ -0,0 +1,347 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	tidutils "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/husobee/vestigo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	maxBulkReadKeys   = 1000
+	maxBulkWriteItems = 10000
+
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// bulkEnvelopeHeaders describe the body of a bulk write request as a whole, so they are not metadata of its documents.
+// The media type of a document is either in its own metadata or the default of its path.
+var bulkEnvelopeHeaders = map[string]bool{"content-type": true, "content-length": true}
+
+func BulkRead(service db.RWService, table string, timeout time.Duration) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		var keys []string
+		if err := json.NewDecoder(request.Body).Decode(&keys); isBodyReadError(err) {
+			writeBodyReadError(writer, err)
+			return
+		} else if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(map[string]string{"message": "request body must be a JSON array of document keys"})
+			return
+		}
+		keys = uniqueKeys(keys)
+		if len(keys) > maxBulkReadKeys {
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(map[string]string{"message": fmt.Sprintf("no more than %d documents may be read at once", maxBulkReadKeys)})
+			return
+		}
+
+		txid := tidutils.GetTransactionIDFromRequest(request)
+
+		ctx, cancelFunc := context.WithTimeout(tidutils.TransactionAwareContext(context.Background(), txid), timeout)
+		defer cancelFunc()
+
+		responseCh := make(chan map[string]db.Document)
+		errorCh := make(chan error)
+
+		go func(responseCh chan map[string]db.Document, errorCh chan error) {
+			docs, err := service.ReadMany(ctx, table, keys)
+
+			if err != nil {
+				errorCh <- err
+				return
+			}
+
+			responseCh <- docs
+
+		}(responseCh, errorCh)
+
+		readLog := log.WithFields(log.Fields{tidutils.TransactionIDKey: txid, "count": len(keys), "table": table})
+
+		select {
+		case <-ctx.Done():
+			readLog.Error("Bulk document read request timed out")
+			writer.WriteHeader(http.StatusGatewayTimeout)
+			json.NewEncoder(writer).Encode(map[string]string{"message": "bulk document read request timed out"})
+
+		case docs := <-responseCh:
+			readLog.WithField("found", len(docs)).Info("Documents read, responding ...")
+			writeBulkReadResponse(writer, keys, docs)
+
+		case err := <-errorCh:
+			writer.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+		}
+	}
+}
+
+// writeBulkReadResponse streams the documents found, in the order they were requested, followed by the keys of the missing documents
+func writeBulkReadResponse(writer http.ResponseWriter, keys []string, docs map[string]db.Document) {
+	enc := json.NewEncoder(writer)
+	missing := []string{}
+
+	io.WriteString(writer, `{"documents":{`)
+	first := true
+	for _, key := range keys {
+		doc, found := docs[key]
+		if !found {
+			missing = append(missing, key)
+			continue
+		}
+
+		if !first {
+			io.WriteString(writer, ",")
+		}
+		first = false
+
+		k, _ := json.Marshal(key)
+		writer.Write(k)
+		io.WriteString(writer, ":")
+		enc.Encode(bulkReadDocument{Body: documentBody(doc.Body), Hash: doc.Hash, Metadata: doc.Metadata})
+	}
+	io.WriteString(writer, `},"missing":`)
+	enc.Encode(missing)
+	io.WriteString(writer, "}")
+}
+
+// documentBody embeds a JSON document as it is, and any other document as a JSON string
+func documentBody(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return body
+	}
+	s, _ := json.Marshal(string(body))
+	return s
+}
+
+func uniqueKeys(keys []string) []string {
+	seen := make(map[string]struct{})
+	unique := []string{}
+	for _, key := range keys {
+		if _, found := seen[key]; !found {
+			seen[key] = struct{}{}
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+func BulkWrite(service db.RWService, table string, timeout time.Duration) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		requestItems, err := decodeBulkWriteItems(request)
+		if isBodyReadError(err) {
+			writeBodyReadError(writer, err)
+			return
+		}
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+			return
+		}
+
+		params := make(map[string]string)
+		for _, p := range vestigo.ParamNames(request) {
+			params[p[1:]] = vestigo.Param(request, p[1:])
+		}
+
+		metadata := db.DocMetadata{}
+		for k := range request.Header {
+			if !bulkEnvelopeHeaders[strings.ToLower(k)] {
+				metadata.Set(strings.ToLower(k), request.Header.Get(k))
+			}
+		}
+		metadata.Set("_timestamp", time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+
+		names := keyParams(request)
+		items := make([]db.BulkWriteItem, len(requestItems))
+		for i, requestItem := range requestItems {
+			if len(names) > 1 && strings.Count(requestItem.Key, db.KeySeparator) < len(names)-1 {
+				writer.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(writer).Encode(map[string]string{"message": fmt.Sprintf("document %d must have a key of %d values separated by %s", i, len(names), db.KeySeparator)})
+				return
+			}
+			items[i] = requestItem.toBulkWriteItem(names, params, metadata)
+			items[i].Err = validateDocument(request, items[i].Document.Body)
+		}
+
+		// start the endpoint timer after we consume the http body
+		txid := tidutils.GetTransactionIDFromRequest(request)
+		ctx, cancelFunc := context.WithTimeout(tidutils.TransactionAwareContext(context.Background(), txid), timeout)
+		defer cancelFunc()
+
+		responseCh := make(chan []db.BulkWriteResult)
+		errorCh := make(chan error)
+
+		go func(responseCh chan []db.BulkWriteResult, errorCh chan error) {
+			results, err := service.BulkWrite(ctx, table, items)
+
+			if err != nil {
+				errorCh <- err
+				return
+			}
+
+			responseCh <- results
+		}(responseCh, errorCh)
+
+		writeLog := log.WithFields(log.Fields{tidutils.TransactionIDKey: txid, "count": len(items), "table": table})
+
+		select {
+		case <-ctx.Done():
+			writeLog.Error("Bulk document write request timed out")
+			writer.WriteHeader(http.StatusGatewayTimeout)
+			json.NewEncoder(writer).Encode(map[string]string{"message": "bulk document write request timed out; the documents of any transaction committed before then have been written"})
+
+		case err := <-errorCh:
+			writer.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+
+		case results := <-responseCh:
+			body := make([]bulkWriteResponseItem, len(results))
+			failures := 0
+			for i, result := range results {
+				body[i] = newBulkWriteResponseItem(result)
+				if result.Err != nil {
+					failures++
+				}
+			}
+
+			if failures > 0 {
+				writeLog.WithField("failures", failures).Warn("Some documents have not been written")
+				writer.WriteHeader(http.StatusMultiStatus)
+			} else {
+				writeLog.Info("Documents have been written")
+			}
+			json.NewEncoder(writer).Encode(body)
+		}
+	}
+}
+
+// decodeBulkWriteItems reads either a JSON array of items or, for application/x-ndjson, one item per line
+func decodeBulkWriteItems(request *http.Request) ([]bulkWriteRequestItem, error) {
+	var items []bulkWriteRequestItem
+	dec := json.NewDecoder(request.Body)
+
+	if strings.HasPrefix(request.Header.Get("Content-Type"), ndjsonContentType) {
+		for {
+			var item bulkWriteRequestItem
+			err := dec.Decode(&item)
+			if err == io.EOF {
+				break
+			}
+			if isBodyReadError(err) {
+				return nil, err
+			}
+			if err != nil {
+				return nil, fmt.Errorf("request body must contain one JSON document per line: %v", err)
+			}
+			items = append(items, item)
+		}
+	} else if err := dec.Decode(&items); isBodyReadError(err) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("request body must be a JSON array of documents: %v", err)
+	}
+
+	if len(items) > maxBulkWriteItems {
+		return nil, fmt.Errorf("no more than %d documents may be written at once", maxBulkWriteItems)
+	}
+
+	for i, item := range items {
+		if item.Key == "" || (len(item.Body) == 0) == (item.Text == nil) {
+			return nil, fmt.Errorf("document %d must have a key, and either a body or a text", i)
+		}
+	}
+
+	return items, nil
+}
+
+type bulkReadDocument struct {
+	Body     json.RawMessage   `json:"body"`
+	Hash     string            `json:"hash"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// bulkWriteRequestItem is a document to write, which is either a JSON body or, for a document that is not JSON, a text
+type bulkWriteRequestItem struct {
+	Key          string            `json:"key"`
+	Body         json.RawMessage   `json:"body"`
+	Text         *string           `json:"text"`
+	PreviousHash string            `json:"previousHash"`
+	Metadata     map[string]string `json:"metadata"`
+}
+
+func (item bulkWriteRequestItem) toBulkWriteItem(keyParams []string, requestParams map[string]string, requestMetadata db.DocMetadata) db.BulkWriteItem {
+	body := []byte(item.Body)
+	if item.Text != nil {
+		body = []byte(*item.Text)
+	}
+
+	doc := db.NewDocument(body)
+	for k, v := range requestMetadata {
+		doc.Metadata.Set(k, v)
+	}
+	for k, v := range item.Metadata {
+		doc.Metadata.Set(strings.ToLower(k), v)
+	}
+
+	params := make(map[string]string)
+	for k, v := range requestParams {
+		params[k] = v
+	}
+	// every value of a composite key is taken from the key of the document, rather than from the route
+	for i, value := range db.SplitKey(item.Key, len(keyParams)) {
+		params[keyParams[i]] = value
+	}
+
+	return db.BulkWriteItem{Key: item.Key, Document: doc, Params: params, PreviousDocumentHash: item.PreviousHash}
+}
+
+type bulkWriteResponseItem struct {
+	Key     string `json:"key"`
+	Status  string `json:"status"`
+	Hash    string `json:"hash,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func newBulkWriteResponseItem(result db.BulkWriteResult) bulkWriteResponseItem {
+	item := bulkWriteResponseItem{Key: result.Key, Hash: result.Hash}
+	if result.Err != nil {
+		item.Hash = ""
+		item.Message = result.Err.Error()
+	}
+
+	switch err := result.Err.(type) {
+	case nil:
+		switch result.Status {
+		case db.Created:
+			item.Status = "created"
+		case db.Unchanged:
+			item.Status = "unchanged"
+		default:
+			item.Status = "updated"
+		}
+	case *db.ConflictError:
+		item.Status = "conflict"
+		item.Hash = err.CurrentHash
+	case *db.RolledBackError:
+		item.Status = "rolledBack"
+	default:
+		if err == db.ErrNotAttempted {
+			item.Status = "notAttempted"
+		} else {
+			item.Status = "error"
+		}
+	}
+	return item
+}
@@ -0,0 +1,326 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	tidutils "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBulkRead(t *testing.T) {
+	jsonDoc := db.NewDocumentWithHash([]byte(docBody), docHash)
+	jsonDoc.Metadata.Set(systemIdHeader, testSystemId)
+	textDoc := db.NewDocumentWithHash([]byte("some text"), prevDocHash)
+
+	rw := &mockRW{}
+	rw.On("ReadMany", mock.AnythingOfType("*context.timerCtx"), testTable, []string{"1", "2", "3"}).Return(map[string]db.Document{"1": jsonDoc, "3": textDoc}, nil)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-read", testTable), BulkRead(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-read", testTable), strings.NewReader(`["1","2","3","1"]`))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content type")
+	body, _ := ioutil.ReadAll(actual.Body)
+	expected := fmt.Sprintf(`{"documents":{"1":{"body":%s,"hash":"%s","metadata":{"%s":"%s"}},"3":{"body":"some text","hash":"%s"}},"missing":["2"]}`,
+		docBody, docHash, systemIdHeader, testSystemId, prevDocHash)
+	assert.JSONEq(t, expected, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestBulkReadInvalidBody(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-read", testTable), BulkRead(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-read", testTable), strings.NewReader(`{"foo":"bar"}`))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+
+	rw.AssertExpectations(t)
+}
+
+func TestBulkReadTooManyKeys(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-read", testTable), BulkRead(rw, testTable, testDefaultTimeout))
+
+	keys := make([]string, maxBulkReadKeys+1)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	reqBody, _ := json.Marshal(keys)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-read", testTable), strings.NewReader(string(reqBody)))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+
+	rw.AssertExpectations(t)
+}
+
+func TestBulkReadError(t *testing.T) {
+	rw := &mockRW{}
+	msg := "Some unexpected error"
+	rw.On("ReadMany", mock.AnythingOfType("*context.timerCtx"), testTable, []string{"1"}).Return(map[string]db.Document{}, errors.New(msg))
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-read", testTable), BulkRead(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-read", testTable), strings.NewReader(`["1"]`))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusInternalServerError, actual.StatusCode, "HTTP status")
+	var errorResponse map[string]string
+	json.NewDecoder(actual.Body).Decode(&errorResponse)
+	assert.Equal(t, msg, errorResponse["message"])
+
+	rw.AssertExpectations(t)
+}
+
+func matchBulkWriteItems(expected ...db.BulkWriteItem) func([]db.BulkWriteItem) bool {
+	return func(items []db.BulkWriteItem) bool {
+		if len(items) != len(expected) {
+			return false
+		}
+		for i, item := range items {
+			if item.Key != expected[i].Key || string(item.Document.Body) != string(expected[i].Document.Body) || item.PreviousDocumentHash != expected[i].PreviousDocumentHash {
+				return false
+			}
+			for k, v := range expected[i].Params {
+				if item.Params[k] != v {
+					return false
+				}
+			}
+			for k, v := range expected[i].Document.Metadata {
+				if item.Document.Metadata[k] != v {
+					return false
+				}
+			}
+			if _, found := item.Document.Metadata["_timestamp"]; !found {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func TestBulkWrite(t *testing.T) {
+	first := db.BulkWriteItem{Key: "1", Document: db.NewDocument([]byte(docBody)), Params: map[string]string{"id": "1"}}
+	first.Document.Metadata.Set(strings.ToLower(tidutils.TransactionIDHeader), testTxId)
+	first.Document.Metadata.Set(strings.ToLower(systemIdHeader), testSystemId)
+	second := db.BulkWriteItem{Key: "2", Document: db.NewDocument([]byte("some text")), Params: map[string]string{"id": "2"}, PreviousDocumentHash: prevDocHash}
+
+	results := []db.BulkWriteResult{
+		{Key: "1", Status: db.Created, Hash: docHash},
+		{Key: "2", Status: db.Updated, Hash: prevDocHash},
+	}
+
+	rw := &mockRW{}
+	rw.On("BulkWrite", mock.AnythingOfType("*context.timerCtx"), testTable, mock.MatchedBy(matchBulkWriteItems(first, second))).Return(results, nil)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-write", testTable), BulkWrite(rw, testTable, testDefaultTimeout))
+
+	reqBody := fmt.Sprintf(`[{"key":"1","body":%s,"metadata":{"%s":"%s"}},{"key":"2","text":"some text","previousHash":"%s"}]`, docBody, systemIdHeader, testSystemId, prevDocHash)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-write", testTable), strings.NewReader(reqBody))
+	req.Header.Set(tidutils.TransactionIDHeader, testTxId)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content type")
+	body, _ := ioutil.ReadAll(actual.Body)
+	expected := fmt.Sprintf(`[{"key":"1","status":"created","hash":"%s"},{"key":"2","status":"updated","hash":"%s"}]`, docHash, prevDocHash)
+	assert.JSONEq(t, expected, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestBulkWriteNDJSON(t *testing.T) {
+	first := db.BulkWriteItem{Key: "1", Document: db.NewDocument([]byte(docBody)), Params: map[string]string{"id": "1"}}
+	second := db.BulkWriteItem{Key: "2", Document: db.NewDocument([]byte(docBody)), Params: map[string]string{"id": "2"}}
+
+	results := []db.BulkWriteResult{
+		{Key: "1", Status: db.Created, Hash: docHash},
+		{Key: "2", Status: db.Unchanged, Hash: docHash},
+	}
+
+	rw := &mockRW{}
+	rw.On("BulkWrite", mock.AnythingOfType("*context.timerCtx"), testTable, mock.MatchedBy(matchBulkWriteItems(first, second))).Return(results, nil)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-write", testTable), BulkWrite(rw, testTable, testDefaultTimeout))
+
+	reqBody := fmt.Sprintf("{\"key\":\"1\",\"body\":%s}\n{\"key\":\"2\",\"body\":%s}\n", docBody, docBody)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-write", testTable), strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	expected := fmt.Sprintf(`[{"key":"1","status":"created","hash":"%s"},{"key":"2","status":"unchanged","hash":"%s"}]`, docHash, docHash)
+	assert.JSONEq(t, expected, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestBulkWriteJSONString(t *testing.T) {
+	item := db.BulkWriteItem{Key: "1", Document: db.NewDocument([]byte(`"hello"`)), Params: map[string]string{"id": "1"}}
+
+	rw := &mockRW{}
+	rw.On("BulkWrite", mock.AnythingOfType("*context.timerCtx"), testTable, mock.MatchedBy(matchBulkWriteItems(item))).Return([]db.BulkWriteResult{{Key: "1", Status: db.Created, Hash: docHash}}, nil)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-write", testTable), BulkWrite(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-write", testTable), strings.NewReader(`[{"key":"1","body":"hello"}]`))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestBulkWriteEnvelopeHeaders(t *testing.T) {
+	results := []db.BulkWriteResult{
+		{Key: "1", Status: db.Created, Hash: docHash},
+		{Key: "2", Status: db.Created, Hash: docHash},
+	}
+
+	rw := &mockRW{}
+	rw.On("BulkWrite", mock.AnythingOfType("*context.timerCtx"), testTable, mock.MatchedBy(func(items []db.BulkWriteItem) bool {
+		_, hasContentType := items[0].Document.Metadata["content-type"]
+		_, hasContentLength := items[0].Document.Metadata["content-length"]
+		return len(items) == 2 && !hasContentType && !hasContentLength &&
+			items[0].Document.Metadata[strings.ToLower(systemIdHeader)] == testSystemId &&
+			items[1].Document.Metadata["content-type"] == "text/plain"
+	})).Return(results, nil)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-write", testTable), BulkWrite(rw, testTable, testDefaultTimeout))
+
+	reqBody := fmt.Sprintf("{\"key\":\"1\",\"body\":%s}\n{\"key\":\"2\",\"text\":\"some text\",\"metadata\":{\"Content-Type\":\"text/plain\"}}\n", docBody)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-write", testTable), strings.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Length", fmt.Sprint(len(reqBody)))
+	req.Header.Set(systemIdHeader, testSystemId)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+
+	rw.AssertExpectations(t)
+}
+
+func TestBulkWriteRolledBack(t *testing.T) {
+	results := []db.BulkWriteResult{
+		{Key: "1", Err: &db.RolledBackError{FailedKey: "2"}},
+		{Key: "2", Err: &db.ConflictError{CurrentHash: prevDocHash}},
+		{Key: "3", Err: db.ErrNotAttempted},
+		{Key: "4", Err: errors.New("Some unexpected error")},
+	}
+
+	rw := &mockRW{}
+	rw.On("BulkWrite", mock.AnythingOfType("*context.timerCtx"), testTable, mock.AnythingOfType("[]db.BulkWriteItem")).Return(results, nil)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-write", testTable), BulkWrite(rw, testTable, testDefaultTimeout))
+
+	reqBody := fmt.Sprintf(`[{"key":"1","body":%s},{"key":"2","body":%s},{"key":"3","body":%s},{"key":"4","body":%s}]`, docBody, docBody, docBody, docBody)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-write", testTable), strings.NewReader(reqBody))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusMultiStatus, actual.StatusCode, "HTTP status")
+	var body []map[string]string
+	json.NewDecoder(actual.Body).Decode(&body)
+	assert.Len(t, body, 4)
+	assert.Equal(t, "rolledBack", body[0]["status"])
+	assert.Equal(t, "conflict", body[1]["status"])
+	assert.Equal(t, prevDocHash, body[1]["hash"])
+	assert.Equal(t, "notAttempted", body[2]["status"])
+	assert.Equal(t, "error", body[3]["status"])
+	assert.Equal(t, "Some unexpected error", body[3]["message"])
+
+	rw.AssertExpectations(t)
+}
+
+func TestBulkWriteInvalidBody(t *testing.T) {
+	for _, reqBody := range []string{`{"key":"1"}`, `[{"key":"1"}]`, `[{"body":{}}]`, `[{"key":"1","body":{},"text":"some text"}]`} {
+		rw := &mockRW{}
+
+		router := vestigo.NewRouter()
+		router.Post(fmt.Sprintf("/%s/__bulk-write", testTable), BulkWrite(rw, testTable, testDefaultTimeout))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-write", testTable), strings.NewReader(reqBody))
+
+		router.ServeHTTP(w, req)
+		actual := w.Result()
+
+		assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status for %s", reqBody)
+
+		rw.AssertExpectations(t)
+	}
+}
+
+func TestBulkWriteError(t *testing.T) {
+	rw := &mockRW{}
+	msg := "Some unexpected error"
+	rw.On("BulkWrite", mock.AnythingOfType("*context.timerCtx"), testTable, mock.AnythingOfType("[]db.BulkWriteItem")).Return([]db.BulkWriteResult{}, errors.New(msg))
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-write", testTable), BulkWrite(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-write", testTable), strings.NewReader(fmt.Sprintf(`[{"key":"1","body":%s}]`, docBody)))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusInternalServerError, actual.StatusCode, "HTTP status")
+	var errorResponse map[string]string
+	json.NewDecoder(actual.Body).Decode(&errorResponse)
+	assert.Equal(t, msg, errorResponse["message"])
+
+	rw.AssertExpectations(t)
+}
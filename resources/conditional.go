@@ -0,0 +1,87 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+)
+
+const (
+	etagHeader        = "ETag"
+	ifMatchHeader     = "If-Match"
+	ifNoneMatchHeader = "If-None-Match"
+)
+
+// entityTag formats a document hash as a strong HTTP entity tag
+func entityTag(hash string) string {
+	return `"` + hash + `"`
+}
+
+// weakTagPrefix marks a weak entity tag, e.g. W/"<hash>"
+const weakTagPrefix = "W/"
+
+// errMultipleEntityTags is returned for an If-Match header listing several entity tags, as a write is conditional on a single document hash
+var errMultipleEntityTags = errors.New("If-Match must have a single entity tag or *")
+
+// parseEntityTags splits a list of entity tags as found in If-Match and If-None-Match,
+// returning the opaque tags without quotes, a weak tag keeping its W/ prefix
+func parseEntityTags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		weak := strings.HasPrefix(tag, weakTagPrefix)
+		tag = strings.Trim(strings.TrimPrefix(tag, weakTagPrefix), `"`)
+		if tag != "" && weak {
+			tag = weakTagPrefix + tag
+		}
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// isNotModified reports whether the If-None-Match header of a read request matches the document hash.
+// If-None-Match uses the weak comparison, so a weak tag matches as well.
+func isNotModified(request *http.Request, hash string) bool {
+	for _, tag := range parseEntityTags(request.Header.Get(ifNoneMatchHeader)) {
+		if tag == "*" || strings.TrimPrefix(tag, weakTagPrefix) == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// previousDocumentHash returns the document hash the client expects to be stored,
+// from either the Previous-Document-Hash header or its standard If-Match / If-None-Match equivalents.
+// If-Match uses the strong comparison, so a weak tag is returned as it is, which never matches a document hash.
+func previousDocumentHash(request *http.Request) (string, error) {
+	if hash := request.Header.Get(previousDocumentHashHeader); hash != "" {
+		return hash, nil
+	}
+
+	if tags := parseEntityTags(request.Header.Get(ifMatchHeader)); len(tags) > 1 {
+		return "", errMultipleEntityTags
+	} else if len(tags) == 1 {
+		if tags[0] == "*" {
+			return db.AnyDocumentHash, nil
+		}
+		return tags[0], nil
+	}
+
+	if strings.TrimSpace(request.Header.Get(ifNoneMatchHeader)) == "*" {
+		return db.NoDocumentHash, nil
+	}
+
+	return "", nil
+}
+
+// writeInvalidPrecondition responds to a request whose conditional headers cannot be applied
+func writeInvalidPrecondition(writer http.ResponseWriter, err error) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+}
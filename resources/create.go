@@ -0,0 +1,142 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/Financial-Times/generic-rw-aurora/expression"
+	tidutils "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/husobee/vestigo"
+	log "github.com/sirupsen/logrus"
+)
+
+// Create writes a new document with a key generated by the id expression, at the document path of a collection.
+// It responds with a 201 Created and the location of the document, or a 409 Conflict if a document already has the generated key.
+func Create(service db.RWService, table string, documentPath string, id expression.Expression, timeout time.Duration) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+
+		params := make(map[string]string)
+		for _, p := range vestigo.ParamNames(request) {
+			params[p[1:]] = vestigo.Param(request, p[1:])
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+
+		docBody, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			writeBodyReadError(writer, err)
+			return
+		}
+
+		doc := db.NewDocument(docBody)
+		for k := range request.Header {
+			v := request.Header.Get(k)
+			doc.Metadata.Set(strings.ToLower(k), v)
+		}
+		doc.Metadata.Set("_timestamp", time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+
+		generated, err := generateKey(id, &expression.Input{Params: params, Metadata: doc.Metadata, Body: docBody})
+		if err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(map[string]string{"message": fmt.Sprintf("Unable to generate a document key: %v", err)})
+			return
+		}
+		params["id"] = generated
+
+		// the generated key is the :id part of a composite primary key
+		names := keyParams(request)
+		keyValues := make([]string, len(names))
+		for i, name := range names {
+			keyValues[i] = params[name]
+		}
+		key := db.JoinKey(keyValues)
+
+		txid := tidutils.GetTransactionIDFromRequest(request)
+		ctx, cancelFunc := context.WithTimeout(tidutils.TransactionAwareContext(context.Background(), txid), timeout)
+		defer cancelFunc()
+
+		responseCh := make(chan statusHashTuple)
+		errorCh := make(chan error)
+
+		go func(responseCh chan statusHashTuple, errorCh chan error) {
+			// a created document must not replace an existing one, whatever the conflict detection of the table
+			status, hash, err := service.Write(ctx, table, key, doc, params, db.NoDocumentHash)
+
+			if err != nil {
+				errorCh <- err
+				return
+			}
+			responseCh <- statusHashTuple{status, hash}
+		}(responseCh, errorCh)
+
+		createLog := log.WithFields(log.Fields{tidutils.TransactionIDKey: txid, "key": key, "table": table})
+
+		select {
+		case <-ctx.Done():
+			createLog.Error("Document create request timed out")
+			writer.WriteHeader(http.StatusGatewayTimeout)
+			json.NewEncoder(writer).Encode(map[string]string{"message": "document create request timed out"})
+
+		case err := <-errorCh:
+			if _, ok := err.(*db.ConflictError); ok {
+				createLog.Warn("Document create rejected as the generated key is already in use")
+				writer.WriteHeader(http.StatusConflict)
+				json.NewEncoder(writer).Encode(map[string]string{"message": fmt.Sprintf("A document with key %s already exists", key)})
+				return
+			}
+			if isInvalidColumnValue(err) {
+				createLog.WithError(err).Warn("Document create rejected due to an invalid or missing column value")
+				writer.WriteHeader(http.StatusBadRequest)
+			} else {
+				writer.WriteHeader(http.StatusInternalServerError)
+			}
+			json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+
+		case statusHashTuple := <-responseCh:
+			writer.Header().Set("Location", documentLocation(documentPath, params))
+			writeWriteStatus(writer, createLog, statusHashTuple)
+		}
+	}
+}
+
+// generateKey evaluates the id expression of a created document, which must be a non-empty string or a number
+func generateKey(id expression.Expression, in *expression.Input) (string, error) {
+	v, err := id.Eval(in)
+	if err != nil {
+		return "", err
+	}
+
+	var key string
+	switch v := v.(type) {
+	case string:
+		key = v
+	case float64:
+		key = strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+	default:
+		return "", fmt.Errorf("the value of %s is not a string or a number", id.String())
+	}
+	if key == "" {
+		return "", fmt.Errorf("the value of %s is empty", id.String())
+	}
+	return key, nil
+}
+
+// documentLocation returns the path of a document, whose route parameters are replaced by the parameters of the request
+func documentLocation(documentPath string, params map[string]string) string {
+	segments := strings.Split(documentPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = url.PathEscape(params[segment[1:]])
+		}
+	}
+	return strings.Join(segments, "/")
+}
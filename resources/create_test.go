@@ -0,0 +1,101 @@
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/Financial-Times/generic-rw-aurora/expression"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func createRouter(t *testing.T, rw db.RWService, id string) *vestigo.Router {
+	expr, err := expression.Parse(id)
+	require.NoError(t, err)
+
+	router := vestigo.NewRouter()
+	router.Post("/drafts/:brand/content", Create(rw, testTable, "/drafts/:brand/content/:id", expr, testDefaultTimeout))
+	return router
+}
+
+func TestCreate(t *testing.T) {
+	var key string
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, mock.MatchedBy(func(k string) bool {
+		key = k
+		return len(k) == 36
+	}), mock.AnythingOfType("db.Document"), mock.AnythingOfType("map[string]string"), db.NoDocumentHash).Return(db.Created, docHash, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/drafts/ft/content", strings.NewReader(docBody))
+
+	createRouter(t, rw, "uuid()").ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusCreated, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "/drafts/ft/content/"+key, actual.Header.Get("Location"))
+	assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+	rw.AssertExpectations(t)
+	params := rw.Calls[0].Arguments.Get(4).(map[string]string)
+	assert.Equal(t, map[string]string{"brand": "ft", "id": key}, params)
+}
+
+func TestCreateWithConfiguredID(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, "ft-bar", mock.AnythingOfType("db.Document"),
+		map[string]string{"brand": "ft", "id": "ft-bar"}, db.NoDocumentHash).Return(db.Created, docHash, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/drafts/ft/content", strings.NewReader(docBody))
+
+	createRouter(t, rw, `concat(:brand, "-", $.foo)`).ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusCreated, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "/drafts/ft/content/ft-bar", actual.Header.Get("Location"))
+	rw.AssertExpectations(t)
+}
+
+func TestCreateWithoutID(t *testing.T) {
+	rw := &mockRW{}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/drafts/ft/content", strings.NewReader(`{"bar":"baz"}`))
+
+	createRouter(t, rw, "$.foo").ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Contains(t, string(body), "Unable to generate a document key")
+	rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateExistingDocument(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, "bar", mock.AnythingOfType("db.Document"),
+		map[string]string{"brand": "ft", "id": "bar"}, db.NoDocumentHash).Return(db.Updated, "", &db.ConflictError{CurrentHash: prevDocHash})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/drafts/ft/content", strings.NewReader(docBody))
+
+	createRouter(t, rw, "$.foo").ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusConflict, actual.StatusCode, "HTTP status")
+	assert.Empty(t, actual.Header.Get("Location"))
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"A document with key bar already exists"}`, string(body))
+}
+
+func TestDocumentLocation(t *testing.T) {
+	assert.Equal(t, "/drafts/content/a%2Fb", documentLocation("/drafts/content/:id", map[string]string{"id": "a/b"}))
+	assert.Equal(t, fmt.Sprintf("/drafts/ft/content/%s/annotations", testKey), documentLocation("/drafts/:brand/content/:id/annotations", map[string]string{"brand": "ft", "id": testKey}))
+}
@@ -0,0 +1,193 @@
+package resources
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	gzipEncoding    = "gzip"
+	deflateEncoding = "deflate"
+)
+
+// ContentEncodingError is returned when reading a request body that cannot be decompressed
+type ContentEncodingError struct {
+	Encoding string
+	Err      error
+}
+
+func (e *ContentEncodingError) Error() string {
+	return fmt.Sprintf("Unable to decode %s request body: %v", e.Encoding, e.Err)
+}
+
+// DecodeBody decompresses a request body with a gzip or deflate Content-Encoding, so that the next handler reads the document as it was sent.
+// A body with any other encoding is rejected with a 415 Unsupported Media Type, and a body that cannot be decompressed with a 400 Bad Request.
+func DecodeBody(next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		encoding := strings.ToLower(strings.TrimSpace(request.Header.Get("Content-Encoding")))
+		var decoded io.ReadCloser
+		var err error
+		switch encoding {
+		case "", "identity":
+			next(writer, request)
+			return
+		case gzipEncoding, "x-gzip":
+			decoded, err = gzip.NewReader(request.Body)
+		case deflateEncoding:
+			decoded, err = newDeflateReader(request.Body)
+		default:
+			writer.Header().Set("Content-Type", "application/json")
+			writer.WriteHeader(http.StatusUnsupportedMediaType)
+			json.NewEncoder(writer).Encode(map[string]string{"message": fmt.Sprintf("Unsupported Content-Encoding %s, expected one of: %s, %s", encoding, gzipEncoding, deflateEncoding)})
+			return
+		}
+		if err != nil {
+			writeBodyReadError(writer, &ContentEncodingError{Encoding: encoding, Err: err})
+			return
+		}
+
+		request.Body = &decodedBody{ReadCloser: decoded, encoding: encoding, body: request.Body}
+		// the decoded length is unknown, and the encoding is not part of the document metadata
+		request.ContentLength = -1
+		request.Header.Del("Content-Encoding")
+		request.Header.Del("Content-Length")
+		next(writer, request)
+	}
+}
+
+// newDeflateReader reads a deflate body, which should be zlib wrapped but is raw deflate from some clients
+func newDeflateReader(body io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(body)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// decodedBody reports a body that cannot be decompressed as a *ContentEncodingError
+type decodedBody struct {
+	io.ReadCloser
+	encoding string
+	body     io.ReadCloser
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = &ContentEncodingError{Encoding: b.encoding, Err: err}
+	}
+	return n, err
+}
+
+func (b *decodedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.body.Close()
+}
+
+// EncodeResponse compresses a response body with gzip or deflate, if the Accept-Encoding of the request allows it
+func EncodeResponse(next http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := acceptedEncoding(request.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			next(writer, request)
+			return
+		}
+
+		encoder := &encodingWriter{ResponseWriter: writer, encoding: encoding}
+		defer encoder.close()
+		next(encoder, request)
+	}
+}
+
+// acceptedEncoding returns the preferred encoding among gzip and deflate according to an Accept-Encoding header, or an empty string for neither
+func acceptedEncoding(acceptEncoding string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if param = strings.TrimSpace(param); strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		qualities[coding] = quality
+	}
+
+	best := ""
+	bestQuality := 0.0
+	for _, coding := range []string{gzipEncoding, deflateEncoding} {
+		quality, found := qualities[coding]
+		if !found {
+			quality, found = qualities["*"]
+		}
+		if found && quality > bestQuality {
+			best = coding
+			bestQuality = quality
+		}
+	}
+	return best
+}
+
+// encodingWriter compresses the body of a response, unless its status does not allow a body
+type encodingWriter struct {
+	http.ResponseWriter
+	encoding    string
+	encoder     io.WriteCloser
+	wroteHeader bool
+}
+
+func (w *encodingWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// the compressed representation is not byte for byte the document the entity tag identifies
+		if etag := header.Get(etagHeader); strings.HasPrefix(etag, `"`) {
+			header.Set(etagHeader, "W/"+etag)
+		}
+		if w.encoding == gzipEncoding {
+			w.encoder = gzip.NewWriter(w.ResponseWriter)
+		} else {
+			w.encoder = zlib.NewWriter(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *encodingWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.encoder == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.encoder.Write(p)
+}
+
+func (w *encodingWriter) close() {
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+}
@@ -0,0 +1,205 @@
+package resources
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func compress(t *testing.T, encoding string, body string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw deflate":
+		var err error
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+		require.NoError(t, err)
+	}
+	_, err := w.Write([]byte(body))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestWriteEncodedBody(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "raw deflate"} {
+		t.Run(encoding, func(t *testing.T) {
+			rw := &mockRW{}
+			rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.MatchedBy(func(doc db.Document) bool {
+				_, hasEncoding := doc.Metadata["content-encoding"]
+				return string(doc.Body) == docBody && !hasEncoding
+			}), map[string]string{"id": testKey}, "").Return(db.Created, docHash, nil)
+
+			router := vestigo.NewRouter()
+			router.Put(fmt.Sprintf("/%s/:id", testTable), DecodeBody(Write(rw, testTable, testDefaultTimeout)))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), bytes.NewReader(compress(t, encoding, docBody)))
+			req.Header.Set("Content-Encoding", strings.Fields(encoding)[len(strings.Fields(encoding))-1])
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Result().StatusCode, "HTTP status")
+			rw.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWriteEncodedBodyLimit(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), DecodeBody(LimitBody(64, Write(rw, testTable, testDefaultTimeout))))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), bytes.NewReader(compress(t, "gzip", strings.Repeat("a", 1024))))
+	req.Header.Set("Content-Encoding", "gzip")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Result().StatusCode, "the limit applies to the decoded body")
+	rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWriteInvalidEncodedBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		status   int
+	}{
+		{"not gzip", "gzip", []byte(docBody), http.StatusBadRequest},
+		{"truncated gzip", "gzip", compress(t, "gzip", docBody)[:20], http.StatusBadRequest},
+		{"unsupported encoding", "br", []byte(docBody), http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rw := &mockRW{}
+
+			router := vestigo.NewRouter()
+			router.Put(fmt.Sprintf("/%s/:id", testTable), DecodeBody(Write(rw, testTable, testDefaultTimeout)))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), bytes.NewReader(test.body))
+			req.Header.Set("Content-Encoding", test.encoding)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.status, w.Result().StatusCode, "HTTP status")
+			rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAcceptedEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    "gzip",
+		"deflate, gzip":           "gzip",
+		"gzip;q=0.5, deflate":     "deflate",
+		"*":                       "gzip",
+		"*, gzip;q=0":             "deflate",
+		"br, GZIP;q=0.8":          "gzip",
+		"gzip;q=0, deflate;q=0.0": "",
+	}
+	for acceptEncoding, expected := range tests {
+		assert.Equal(t, expected, acceptedEncoding(acceptEncoding), acceptEncoding)
+	}
+}
+
+func TestReadEncodedResponse(t *testing.T) {
+	doc := db.NewDocument([]byte(docBody))
+	doc.Hash = docHash
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), EncodeResponse(Read(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "gzip", actual.Header.Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", actual.Header.Get("Vary"))
+	assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+	assert.Equal(t, `W/"`+docHash+`"`, actual.Header.Get(etagHeader))
+
+	gz, err := gzip.NewReader(actual.Body)
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, docBody, string(body))
+}
+
+func TestReadEncodedResponseNotModified(t *testing.T) {
+	doc := db.NewDocument([]byte(docBody))
+	doc.Hash = docHash
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), EncodeResponse(Read(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("If-None-Match", `W/"`+docHash+`"`)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusNotModified, actual.StatusCode, "HTTP status")
+	assert.Empty(t, actual.Header.Get("Content-Encoding"))
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Empty(t, body)
+}
+
+func TestReadWithoutAcceptEncoding(t *testing.T) {
+	doc := db.NewDocument([]byte(docBody))
+	doc.Hash = docHash
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), EncodeResponse(Read(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Empty(t, actual.Header.Get("Content-Encoding"))
+	assert.Equal(t, `"`+docHash+`"`, actual.Header.Get(etagHeader))
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Equal(t, docBody, string(body))
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,11 +20,22 @@ import (
 const (
 	errNotFound = "No document found."
 
+	defaultListLimit = 100
+	maxListLimit     = 1000
+
 	documentHashHeader         = "Document-Hash"
 	previousDocumentHashHeader = "Previous-Document-Hash"
+	documentUnchangedHeader    = "X-Document-Unchanged"
 )
 
 func Read(service db.RWService, table string, timeout time.Duration) http.HandlerFunc {
+	return readDocument(table, timeout, func(ctx context.Context, request *http.Request) (db.Document, error) {
+		return service.Read(ctx, table, documentKey(request))
+	})
+}
+
+// readDocument responds with the document returned by the read function, and its hash and metadata as headers
+func readDocument(table string, timeout time.Duration, read func(ctx context.Context, request *http.Request) (db.Document, error)) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
 		txid := tidutils.GetTransactionIDFromRequest(request)
 
@@ -31,10 +44,10 @@ func Read(service db.RWService, table string, timeout time.Duration) http.Handle
 
 		responseCh := make(chan db.Document)
 		errorCh := make(chan error)
-		id := vestigo.Param(request, "id")
+		id := documentKey(request)
 
 		go func(responseCh chan db.Document, errorCh chan error) {
-			doc, err := service.Read(ctx, table, id)
+			doc, err := read(ctx, request)
 
 			if err != nil {
 				errorCh <- err
@@ -57,10 +70,22 @@ func Read(service db.RWService, table string, timeout time.Duration) http.Handle
 
 		case doc := <-responseCh:
 			readLog.Info("Document found, responding ...")
+			mediaType := documentMediaType(request, doc.Metadata)
+			// the Accept header is only negotiated on paths declaring the media types they produce
+			if len(producedMediaTypes(request)) > 0 && !isAcceptable(request.Header.Get("Accept"), mediaType) {
+				writeNotAcceptable(writer, []string{mediaType})
+				return
+			}
+			writer.Header().Set("Content-Type", mediaType)
 			writer.Header().Set(documentHashHeader, doc.Hash)
+			writer.Header().Set(etagHeader, entityTag(doc.Hash))
 			for k, v := range doc.Metadata {
 				writer.Header().Set(k, v)
 			}
+			if isNotModified(request, doc.Hash) {
+				writer.WriteHeader(http.StatusNotModified)
+				return
+			}
 			writer.Write(doc.Body)
 
 		case err := <-errorCh:
@@ -85,15 +110,19 @@ func Write(service db.RWService, table string, timeout time.Duration) http.Handl
 		for _, p := range vestigo.ParamNames(request) {
 			params[p[1:]] = vestigo.Param(request, p[1:])
 		}
-		id := vestigo.Param(request, "id")
+		id := documentKey(request)
 
 		writer.Header().Set("Content-Type", "application/json")
 
+		previousDocHash, err := previousDocumentHash(request)
+		if err != nil {
+			writeInvalidPrecondition(writer, err)
+			return
+		}
+
 		docBody, err := ioutil.ReadAll(request.Body)
 		if err != nil {
-			writer.WriteHeader(http.StatusInternalServerError)
-			body := map[string]string{"message": err.Error()}
-			json.NewEncoder(writer).Encode(body)
+			writeBodyReadError(writer, err)
 			return
 		}
 
@@ -115,8 +144,6 @@ func Write(service db.RWService, table string, timeout time.Duration) http.Handl
 
 			doc.Metadata.Set("_timestamp", time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
 
-			previousDocHash := request.Header.Get(previousDocumentHashHeader)
-
 			status, hash, err := service.Write(ctx, table, id, doc, params, previousDocHash)
 
 			if err != nil {
@@ -135,24 +162,198 @@ func Write(service db.RWService, table string, timeout time.Duration) http.Handl
 			json.NewEncoder(writer).Encode(map[string]string{"message": "document write request timed out"})
 
 		case err := <-errorCh:
-			writer.WriteHeader(http.StatusInternalServerError)
+			if conflict, ok := err.(*db.ConflictError); ok {
+				writeLog.Warn("Document write rejected due to a hash conflict")
+				writePreconditionFailed(writer, conflict)
+				return
+			}
+			if isInvalidColumnValue(err) {
+				writeLog.WithError(err).Warn("Document write rejected due to an invalid or missing column value")
+				writer.WriteHeader(http.StatusBadRequest)
+			} else {
+				writer.WriteHeader(http.StatusInternalServerError)
+			}
 			body := map[string]string{"message": err.Error()}
 			json.NewEncoder(writer).Encode(body)
 
 		case statusHashTuple := <-responseCh:
-			writer.Header().Set(documentHashHeader, statusHashTuple.hash)
-			if statusHashTuple.status == db.Created {
-				writer.WriteHeader(http.StatusCreated)
-				writeLog.Info("Document has been created")
+			writeWriteStatus(writer, writeLog, statusHashTuple)
+		}
+	}
+}
+
+// isInvalidColumnValue returns whether a write failed because of the values in the request, rather than the database
+func isInvalidColumnValue(err error) bool {
+	switch err.(type) {
+	case *db.ValueError, *db.MissingValuesError:
+		return true
+	}
+	return false
+}
+
+// writeWriteStatus responds with the outcome of a successful write
+func writeWriteStatus(writer http.ResponseWriter, writeLog *log.Entry, statusHashTuple statusHashTuple) {
+	writer.Header().Set(documentHashHeader, statusHashTuple.hash)
+	writer.Header().Set(etagHeader, entityTag(statusHashTuple.hash))
+	switch statusHashTuple.status {
+	case db.Created:
+		writer.WriteHeader(http.StatusCreated)
+		writeLog.Info("Document has been created")
+	case db.Unchanged:
+		writer.Header().Set(documentUnchangedHeader, "true")
+		writer.WriteHeader(http.StatusOK)
+		writeLog.Info("Document is unchanged")
+	default:
+		writer.WriteHeader(http.StatusOK)
+		writeLog.Info("Document has been updated")
+	}
+}
+
+func Delete(service db.RWService, table string, timeout time.Duration) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		txid := tidutils.GetTransactionIDFromRequest(request)
+
+		ctx, cancelFunc := context.WithTimeout(tidutils.TransactionAwareContext(context.Background(), txid), timeout)
+		defer cancelFunc()
+
+		responseCh := make(chan struct{})
+		errorCh := make(chan error)
+		id := documentKey(request)
+		previousDocHash, err := previousDocumentHash(request)
+		if err != nil {
+			writeInvalidPrecondition(writer, err)
+			return
+		}
+		if previousDocHash == db.NoDocumentHash {
+			// If-None-Match: * has no meaning for a delete
+			previousDocHash = ""
+		}
+
+		go func(responseCh chan struct{}, errorCh chan error) {
+			err := service.Delete(ctx, table, id, previousDocHash)
+
+			if err != nil {
+				errorCh <- err
+				return
+			}
+
+			responseCh <- struct{}{}
+
+		}(responseCh, errorCh)
+
+		writer.Header().Set("Content-Type", "application/json")
+
+		deleteLog := log.WithFields(log.Fields{tidutils.TransactionIDKey: txid, "key": id, "table": table})
+
+		select {
+		case <-ctx.Done():
+			deleteLog.Error("Document delete request timed out")
+			writer.WriteHeader(http.StatusGatewayTimeout)
+			json.NewEncoder(writer).Encode(map[string]string{"message": "document delete request timed out"})
+
+		case <-responseCh:
+			deleteLog.Info("Document has been deleted")
+			writer.WriteHeader(http.StatusNoContent)
+
+		case err := <-errorCh:
+			if conflict, ok := err.(*db.ConflictError); ok {
+				deleteLog.Warn("Document delete rejected due to a hash conflict")
+				writePreconditionFailed(writer, conflict)
+				return
+			}
+			body := map[string]string{}
+			if err == sql.ErrNoRows {
+				deleteLog.Info("Document is missing")
+				writer.WriteHeader(http.StatusNotFound)
+				body["message"] = errNotFound
 			} else {
-				writer.WriteHeader(http.StatusOK)
-				writeLog.Info("Document has been updated")
+				writer.WriteHeader(http.StatusInternalServerError)
+				body["message"] = err.Error()
 			}
+			json.NewEncoder(writer).Encode(body)
 		}
 	}
 }
 
+func List(service db.RWService, table string, timeout time.Duration) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		after := request.URL.Query().Get("after")
+		limit := defaultListLimit
+		if l := request.URL.Query().Get("limit"); l != "" {
+			var err error
+			limit, err = strconv.Atoi(l)
+			if err != nil || limit < 1 || limit > maxListLimit {
+				writer.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(writer).Encode(map[string]string{"message": fmt.Sprintf("limit must be a number between 1 and %d", maxListLimit)})
+				return
+			}
+		}
+
+		txid := tidutils.GetTransactionIDFromRequest(request)
+
+		ctx, cancelFunc := context.WithTimeout(tidutils.TransactionAwareContext(context.Background(), txid), timeout)
+		defer cancelFunc()
+
+		responseCh := make(chan db.Page)
+		errorCh := make(chan error)
+
+		go func(responseCh chan db.Page, errorCh chan error) {
+			page, err := service.List(ctx, table, after, limit)
+
+			if err != nil {
+				errorCh <- err
+				return
+			}
+
+			responseCh <- page
+
+		}(responseCh, errorCh)
+
+		listLog := log.WithFields(log.Fields{tidutils.TransactionIDKey: txid, "after": after, "table": table})
+
+		select {
+		case <-ctx.Done():
+			listLog.Error("Document list request timed out")
+			writer.WriteHeader(http.StatusGatewayTimeout)
+			json.NewEncoder(writer).Encode(map[string]string{"message": "document list request timed out"})
+
+		case page := <-responseCh:
+			listLog.WithField("count", len(page.Items)).Info("Documents listed, responding ...")
+			body := listResponse{Items: make([]listItem, len(page.Items)), Next: page.Next}
+			for i, item := range page.Items {
+				body.Items[i] = listItem{Key: item.Key, Metadata: item.Metadata}
+			}
+			json.NewEncoder(writer).Encode(body)
+
+		case err := <-errorCh:
+			writer.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+		}
+	}
+}
+
+func writePreconditionFailed(writer http.ResponseWriter, conflict *db.ConflictError) {
+	if conflict.CurrentHash != "" {
+		writer.Header().Set(documentHashHeader, conflict.CurrentHash)
+		writer.Header().Set(etagHeader, entityTag(conflict.CurrentHash))
+	}
+	writer.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(writer).Encode(map[string]string{"message": conflict.Error()})
+}
+
 type statusHashTuple struct {
-	status bool
+	status db.WriteStatus
 	hash   string
 }
+
+type listResponse struct {
+	Items []listItem `json:"items"`
+	Next  string     `json:"next,omitempty"`
+}
+
+type listItem struct {
+	Key      string            `json:"key"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
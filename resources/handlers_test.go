@@ -26,6 +26,7 @@ const (
 	docBody            = `{"foo":"bar"}`
 	readTimeoutBody    = "{\"message\":\"document read request timed out\"}\n"
 	writeTimeoutBody   = "{\"message\":\"document write request timed out\"}\n"
+	deleteTimeoutBody  = "{\"message\":\"document delete request timed out\"}\n"
 	docHash            = "34563ba43d923189d9e3aefd038683ac4f1f1eab72c2684926220d08"
 	prevDocHash        = "bfd86d638f3ffda37b45ddf35fb29ee387f3bb8df5278db4b40e9e72"
 	systemIdHeader     = "X-Origin-System-Id"
@@ -43,9 +44,49 @@ func (m *mockRW) Read(ctx context.Context, table string, key string) (db.Documen
 	return args.Get(0).(db.Document), args.Error(1)
 }
 
-func (m *mockRW) Write(ctx context.Context, table string, key string, doc db.Document, params map[string]string, previousDocumentHash string) (bool, string, error) {
+func (m *mockRW) ReadMany(ctx context.Context, table string, keys []string) (map[string]db.Document, error) {
+	args := m.Called(ctx, table, keys)
+	return args.Get(0).(map[string]db.Document), args.Error(1)
+}
+
+func (m *mockRW) Write(ctx context.Context, table string, key string, doc db.Document, params map[string]string, previousDocumentHash string) (db.WriteStatus, string, error) {
 	args := m.Called(ctx, table, key, doc, params, previousDocumentHash)
-	return args.Bool(0), args.String(1), args.Error(2)
+	return args.Get(0).(db.WriteStatus), args.String(1), args.Error(2)
+}
+
+func (m *mockRW) Delete(ctx context.Context, table string, key string, previousDocumentHash string) error {
+	args := m.Called(ctx, table, key, previousDocumentHash)
+	return args.Error(0)
+}
+
+func (m *mockRW) List(ctx context.Context, table string, after string, limit int) (db.Page, error) {
+	args := m.Called(ctx, table, after, limit)
+	return args.Get(0).(db.Page), args.Error(1)
+}
+
+func (m *mockRW) BulkWrite(ctx context.Context, table string, items []db.BulkWriteItem) ([]db.BulkWriteResult, error) {
+	args := m.Called(ctx, table, items)
+	return args.Get(0).([]db.BulkWriteResult), args.Error(1)
+}
+
+func (m *mockRW) Patch(ctx context.Context, table string, key string, patch func([]byte) ([]byte, error), doc db.Document, params map[string]string, previousDocumentHash string) (db.WriteStatus, string, error) {
+	args := m.Called(ctx, table, key, patch, doc, params, previousDocumentHash)
+	return args.Get(0).(db.WriteStatus), args.String(1), args.Error(2)
+}
+
+func (m *mockRW) History(ctx context.Context, table string, key string) ([]db.Version, error) {
+	args := m.Called(ctx, table, key)
+	return args.Get(0).([]db.Version), args.Error(1)
+}
+
+func (m *mockRW) ReadVersion(ctx context.Context, table string, key string, hash string) (db.Document, error) {
+	args := m.Called(ctx, table, key, hash)
+	return args.Get(0).(db.Document), args.Error(1)
+}
+
+func (m *mockRW) Restore(ctx context.Context, table string, key string, hash string, doc db.Document, params map[string]string, previousDocumentHash string) (db.WriteStatus, string, error) {
+	args := m.Called(ctx, table, key, hash, doc, params, previousDocumentHash)
+	return args.Get(0).(db.WriteStatus), args.String(1), args.Error(2)
 }
 
 type mockReader struct {
@@ -78,11 +119,61 @@ func TestRead(t *testing.T) {
 	body, _ := ioutil.ReadAll(actual.Body)
 	assert.Equal(t, docBody, string(body), "response body")
 	assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+	assert.Equal(t, `"`+docHash+`"`, actual.Header.Get("ETag"))
 	assert.Empty(t, actual.Header.Get(systemIdHeader))
 
 	rw.AssertExpectations(t)
 }
 
+func TestReadNotModified(t *testing.T) {
+	doc := db.NewDocument([]byte(docBody))
+	doc.Hash = docHash
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), Read(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set("If-None-Match", `"`+prevDocHash+`", W/"`+docHash+`"`)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusNotModified, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Empty(t, body, "response body")
+	assert.Equal(t, `"`+docHash+`"`, actual.Header.Get("ETag"))
+
+	rw.AssertExpectations(t)
+}
+
+func TestReadModified(t *testing.T) {
+	doc := db.NewDocument([]byte(docBody))
+	doc.Hash = docHash
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), Read(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set("If-None-Match", `"`+prevDocHash+`"`)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Equal(t, docBody, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
 func TestReadNotFound(t *testing.T) {
 	rw := &mockRW{}
 
@@ -221,7 +312,7 @@ func TestWriteCreate(t *testing.T) {
 	))
 
 	rw := &mockRW{}
-	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, docMatcher, map[string]string{"id": testKey}, "").Return(true, docHash, nil)
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, docMatcher, map[string]string{"id": testKey}, "").Return(db.Created, docHash, nil)
 
 	router := vestigo.NewRouter()
 	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
@@ -242,7 +333,7 @@ func TestWriteCreate(t *testing.T) {
 
 func TestWriteUpdate(t *testing.T) {
 	rw := &mockRW{}
-	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, prevDocHash).Return(false, docHash, nil)
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, prevDocHash).Return(db.Updated, docHash, nil)
 
 	router := vestigo.NewRouter()
 	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
@@ -260,10 +351,86 @@ func TestWriteUpdate(t *testing.T) {
 	rw.AssertExpectations(t)
 }
 
+func TestWriteUnchanged(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, "").Return(db.Unchanged, docHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+	assert.Equal(t, "true", actual.Header.Get(documentUnchangedHeader))
+
+	rw.AssertExpectations(t)
+}
+
+func TestWriteWithConditionalHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		value        string
+		expectedHash string
+	}{
+		{"If-Match with an entity tag", "If-Match", `"` + prevDocHash + `"`, prevDocHash},
+		{"If-Match with any entity tag", "If-Match", "*", db.AnyDocumentHash},
+		{"If-None-Match with any entity tag", "If-None-Match", "*", db.NoDocumentHash},
+		{"If-Match with a weak entity tag, which never matches", "If-Match", `W/"` + prevDocHash + `"`, "W/" + prevDocHash},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rw := &mockRW{}
+			rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, test.expectedHash).Return(db.Updated, docHash, nil)
+
+			router := vestigo.NewRouter()
+			router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+			req.Header.Set(test.header, test.value)
+
+			router.ServeHTTP(w, req)
+			actual := w.Result()
+
+			assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+			assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+			assert.Equal(t, `"`+docHash+`"`, actual.Header.Get("ETag"))
+
+			rw.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWriteWithSeveralIfMatchEntityTags(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+	req.Header.Set("If-Match", `"`+prevDocHash+`", "`+docHash+`"`)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"If-Match must have a single entity tag or *"}`, string(body))
+	rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestWriteError(t *testing.T) {
 	rw := &mockRW{}
 	msg := "Some unexpected error"
-	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, prevDocHash).Return(false, "", errors.New(msg))
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, prevDocHash).Return(db.Updated, "", errors.New(msg))
 
 	router := vestigo.NewRouter()
 	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
@@ -284,6 +451,94 @@ func TestWriteError(t *testing.T) {
 	rw.AssertExpectations(t)
 }
 
+func TestWriteInvalidColumnValue(t *testing.T) {
+	rw := &mockRW{}
+	valueErr := &db.ValueError{Column: "version", Type: "int", Err: errors.New("not a number")}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, "").Return(db.Updated, "", valueErr)
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+	var errorResponse map[string]string
+	json.NewDecoder(actual.Body).Decode(&errorResponse)
+	assert.Equal(t, "invalid value for int column version: not a number", errorResponse["message"])
+
+	rw.AssertExpectations(t)
+}
+
+func TestWriteMissingRequiredValues(t *testing.T) {
+	rw := &mockRW{}
+	missingErr := &db.MissingValuesError{Columns: []string{"origin_system (@.x-origin-system-id)"}}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, "").Return(db.Updated, "", missingErr)
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+	var errorResponse map[string]string
+	json.NewDecoder(actual.Body).Decode(&errorResponse)
+	assert.Equal(t, "missing required value(s) for column(s): origin_system (@.x-origin-system-id)", errorResponse["message"])
+
+	rw.AssertExpectations(t)
+}
+
+func TestWriteConflict(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, prevDocHash).Return(db.Updated, "", &db.ConflictError{CurrentHash: docHash})
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+	req.Header.Set(previousDocumentHashHeader, prevDocHash)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusPreconditionFailed, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content type")
+	assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+	var errorResponse map[string]string
+	json.NewDecoder(actual.Body).Decode(&errorResponse)
+	assert.Contains(t, errorResponse["message"], docHash)
+
+	rw.AssertExpectations(t)
+}
+
+func TestWriteConflictWithMissingDocument(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, prevDocHash).Return(db.Updated, "", &db.ConflictError{})
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+	req.Header.Set(previousDocumentHashHeader, prevDocHash)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusPreconditionFailed, actual.StatusCode, "HTTP status")
+	assert.Empty(t, actual.Header.Get(documentHashHeader))
+
+	rw.AssertExpectations(t)
+}
+
 func TestWriteEntityReadError(t *testing.T) {
 	doc := db.NewDocument([]byte(docBody))
 	doc.Hash = docHash
@@ -326,7 +581,7 @@ func TestWriteTimeout(t *testing.T) {
 	rw := &mockRW{}
 	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, docMatcher, map[string]string{"id": testKey}, "").Run(func(args mock.Arguments) {
 		time.Sleep(500 * time.Millisecond)
-	}).Return(true, docHash, nil)
+	}).Return(db.Created, docHash, nil)
 
 	router := vestigo.NewRouter()
 	router.Put(fmt.Sprintf("/%s/:id", testTable), Write(rw, testTable, 200*time.Millisecond))
@@ -346,3 +601,219 @@ func TestWriteTimeout(t *testing.T) {
 
 	rw.AssertExpectations(t)
 }
+
+func TestDelete(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Delete", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, prevDocHash).Return(nil)
+
+	router := vestigo.NewRouter()
+	router.Delete(fmt.Sprintf("/%s/:id", testTable), Delete(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set(previousDocumentHashHeader, prevDocHash)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusNoContent, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Empty(t, body, "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestDeleteWithIfMatch(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Delete", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, prevDocHash).Return(nil)
+
+	router := vestigo.NewRouter()
+	router.Delete(fmt.Sprintf("/%s/:id", testTable), Delete(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set("If-Match", `"`+prevDocHash+`"`)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusNoContent, actual.StatusCode, "HTTP status")
+
+	rw.AssertExpectations(t)
+}
+
+func TestDeleteNotFound(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Delete", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, "").Return(sql.ErrNoRows)
+
+	router := vestigo.NewRouter()
+	router.Delete(fmt.Sprintf("/%s/:id", testTable), Delete(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusNotFound, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content type")
+	var errorResponse map[string]string
+	json.NewDecoder(actual.Body).Decode(&errorResponse)
+	assert.Equal(t, "No document found.", errorResponse["message"])
+
+	rw.AssertExpectations(t)
+}
+
+func TestDeleteError(t *testing.T) {
+	rw := &mockRW{}
+	msg := "Some unexpected error"
+	rw.On("Delete", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, "").Return(errors.New(msg))
+
+	router := vestigo.NewRouter()
+	router.Delete(fmt.Sprintf("/%s/:id", testTable), Delete(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusInternalServerError, actual.StatusCode, "HTTP status")
+	var errorResponse map[string]string
+	json.NewDecoder(actual.Body).Decode(&errorResponse)
+	assert.Equal(t, msg, errorResponse["message"])
+
+	rw.AssertExpectations(t)
+}
+
+func TestDeleteConflict(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Delete", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, prevDocHash).Return(&db.ConflictError{CurrentHash: docHash})
+
+	router := vestigo.NewRouter()
+	router.Delete(fmt.Sprintf("/%s/:id", testTable), Delete(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set(previousDocumentHashHeader, prevDocHash)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusPreconditionFailed, actual.StatusCode, "HTTP status")
+	assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+
+	rw.AssertExpectations(t)
+}
+
+func TestDeleteTimeout(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Delete", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, "").Run(func(args mock.Arguments) {
+		time.Sleep(500 * time.Millisecond)
+	}).Return(nil)
+
+	router := vestigo.NewRouter()
+	router.Delete(fmt.Sprintf("/%s/:id", testTable), Delete(rw, testTable, 200*time.Millisecond))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+	body, _ := ioutil.ReadAll(actual.Body)
+
+	assert.Equal(t, http.StatusGatewayTimeout, actual.StatusCode, "HTTP status")
+	assert.Equal(t, deleteTimeoutBody, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestList(t *testing.T) {
+	page := db.Page{
+		Items: []db.ListItem{
+			{Key: "1235", Metadata: db.DocMetadata{"last_modified": "2017-10-27T12:00:00.000Z"}},
+			{Key: "1236", Metadata: db.DocMetadata{"last_modified": "2017-10-27T13:00:00.000Z"}},
+		},
+		Next: "1236",
+	}
+
+	rw := &mockRW{}
+	rw.On("List", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, 2).Return(page, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s", testTable), List(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s?after=%s&limit=2", testTable, testKey), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content type")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"items":[{"key":"1235","metadata":{"last_modified":"2017-10-27T12:00:00.000Z"}},{"key":"1236","metadata":{"last_modified":"2017-10-27T13:00:00.000Z"}}],"next":"1236"}`, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestListDefaults(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("List", mock.AnythingOfType("*context.timerCtx"), testTable, "", defaultListLimit).Return(db.Page{Items: []db.ListItem{}}, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s", testTable), List(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s", testTable), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"items":[]}`, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestListInvalidLimit(t *testing.T) {
+	for _, limit := range []string{"foo", "0", "1001"} {
+		rw := &mockRW{}
+
+		router := vestigo.NewRouter()
+		router.Get(fmt.Sprintf("/%s", testTable), List(rw, testTable, testDefaultTimeout))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/%s?limit=%s", testTable, limit), nil)
+
+		router.ServeHTTP(w, req)
+		actual := w.Result()
+
+		assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status for limit %s", limit)
+
+		rw.AssertExpectations(t)
+	}
+}
+
+func TestListError(t *testing.T) {
+	rw := &mockRW{}
+	msg := "Some unexpected error"
+	rw.On("List", mock.AnythingOfType("*context.timerCtx"), testTable, "", defaultListLimit).Return(db.Page{}, errors.New(msg))
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s", testTable), List(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s", testTable), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusInternalServerError, actual.StatusCode, "HTTP status")
+	var errorResponse map[string]string
+	json.NewDecoder(actual.Body).Decode(&errorResponse)
+	assert.Equal(t, msg, errorResponse["message"])
+
+	rw.AssertExpectations(t)
+}
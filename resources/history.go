@@ -0,0 +1,162 @@
+package resources
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	tidutils "github.com/Financial-Times/transactionid-utils-go"
+	"github.com/husobee/vestigo"
+	log "github.com/sirupsen/logrus"
+)
+
+const errVersionNotFound = "No document version found."
+
+// History lists the superseded versions of a document, most recent first
+func History(service db.RWService, table string, timeout time.Duration) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		txid := tidutils.GetTransactionIDFromRequest(request)
+
+		ctx, cancelFunc := context.WithTimeout(tidutils.TransactionAwareContext(context.Background(), txid), timeout)
+		defer cancelFunc()
+
+		responseCh := make(chan []db.Version)
+		errorCh := make(chan error)
+		id := documentKey(request)
+
+		go func(responseCh chan []db.Version, errorCh chan error) {
+			versions, err := service.History(ctx, table, id)
+
+			if err != nil {
+				errorCh <- err
+				return
+			}
+
+			responseCh <- versions
+
+		}(responseCh, errorCh)
+
+		writer.Header().Set("Content-Type", "application/json")
+
+		historyLog := log.WithFields(log.Fields{tidutils.TransactionIDKey: txid, "key": id, "table": table})
+
+		select {
+		case <-ctx.Done():
+			historyLog.Error("Document history request timed out")
+			writer.WriteHeader(http.StatusGatewayTimeout)
+			json.NewEncoder(writer).Encode(map[string]string{"message": "document history request timed out"})
+
+		case versions := <-responseCh:
+			historyLog.WithField("count", len(versions)).Info("Document history found, responding ...")
+			body := historyResponse{Versions: make([]historyVersion, len(versions))}
+			for i, version := range versions {
+				body.Versions[i] = historyVersion{Hash: version.Hash, Metadata: version.Metadata}
+			}
+			json.NewEncoder(writer).Encode(body)
+
+		case err := <-errorCh:
+			writer.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+		}
+	}
+}
+
+// ReadVersion responds with a superseded version of a document, identified by its hash
+func ReadVersion(service db.RWService, table string, timeout time.Duration) http.HandlerFunc {
+	return readDocument(table, timeout, func(ctx context.Context, request *http.Request) (db.Document, error) {
+		return service.ReadVersion(ctx, table, documentKey(request), vestigo.Param(request, "hash"))
+	})
+}
+
+// Restore writes a superseded version of a document, identified by its hash, as a new version of the document
+func Restore(service db.RWService, table string, timeout time.Duration) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+
+		params := make(map[string]string)
+		for _, p := range vestigo.ParamNames(request) {
+			params[p[1:]] = vestigo.Param(request, p[1:])
+		}
+		id := documentKey(request)
+		hash := vestigo.Param(request, "hash")
+
+		writer.Header().Set("Content-Type", "application/json")
+
+		previousDocHash, err := previousDocumentHash(request)
+		if err != nil {
+			writeInvalidPrecondition(writer, err)
+			return
+		}
+
+		txid := tidutils.GetTransactionIDFromRequest(request)
+		ctx, cancelFunc := context.WithTimeout(tidutils.TransactionAwareContext(context.Background(), txid), timeout)
+		defer cancelFunc()
+
+		responseCh := make(chan statusHashTuple)
+		errorCh := make(chan error)
+
+		go func(responseCh chan statusHashTuple, errorCh chan error) {
+			doc := db.NewDocument(nil)
+			for k := range request.Header {
+				v := request.Header.Get(k)
+				doc.Metadata.Set(strings.ToLower(k), v)
+			}
+			// the restore request has no body, so the media type of the restored version is kept
+			delete(doc.Metadata, "content-type")
+			// the restore is a write in its own right, so it is recorded with its own transaction id
+			doc.Metadata.Set(strings.ToLower(tidutils.TransactionIDHeader), txid)
+			doc.Metadata.Set("_timestamp", time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+
+			status, newHash, err := service.Restore(ctx, table, id, hash, doc, params, previousDocHash)
+
+			if err != nil {
+				errorCh <- err
+				return
+			}
+			responseCh <- statusHashTuple{status, newHash}
+		}(responseCh, errorCh)
+
+		restoreLog := log.WithFields(log.Fields{tidutils.TransactionIDKey: txid, "key": id, "table": table, "hash": hash})
+
+		select {
+		case <-ctx.Done():
+			restoreLog.Error("Document restore request timed out")
+			writer.WriteHeader(http.StatusGatewayTimeout)
+			json.NewEncoder(writer).Encode(map[string]string{"message": "document restore request timed out"})
+
+		case err := <-errorCh:
+			if conflict, ok := err.(*db.ConflictError); ok {
+				restoreLog.Warn("Document restore rejected due to a hash conflict")
+				writePreconditionFailed(writer, conflict)
+				return
+			}
+			if err == sql.ErrNoRows {
+				restoreLog.Info("Document version is missing")
+				writer.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(writer).Encode(map[string]string{"message": errVersionNotFound})
+				return
+			}
+			if isInvalidColumnValue(err) {
+				writer.WriteHeader(http.StatusBadRequest)
+			} else {
+				writer.WriteHeader(http.StatusInternalServerError)
+			}
+			json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+
+		case statusHashTuple := <-responseCh:
+			writeWriteStatus(writer, restoreLog, statusHashTuple)
+		}
+	}
+}
+
+type historyResponse struct {
+	Versions []historyVersion `json:"versions"`
+}
+
+type historyVersion struct {
+	Hash     string            `json:"hash"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
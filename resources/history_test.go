@@ -0,0 +1,215 @@
+package resources
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHistory(t *testing.T) {
+	versions := []db.Version{
+		{Hash: docHash, Metadata: db.DocMetadata{"publish_ref": "tid_2"}},
+		{Hash: prevDocHash, Metadata: db.DocMetadata{"publish_ref": "tid_1"}},
+	}
+
+	rw := &mockRW{}
+	rw.On("History", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(versions, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id/__history", testTable), History(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s/__history", testTable, testKey), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content type")
+	body, _ := ioutil.ReadAll(actual.Body)
+	expected := fmt.Sprintf(`{"versions":[{"hash":"%s","metadata":{"publish_ref":"tid_2"}},{"hash":"%s","metadata":{"publish_ref":"tid_1"}}]}`, docHash, prevDocHash)
+	assert.JSONEq(t, expected, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestHistoryEmpty(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("History", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return([]db.Version{}, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id/__history", testTable), History(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s/__history", testTable, testKey), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"versions":[]}`, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestHistoryError(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("History", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return([]db.Version{}, errors.New("test error"))
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id/__history", testTable), History(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s/__history", testTable, testKey), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusInternalServerError, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"test error"}`, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestReadVersion(t *testing.T) {
+	doc := db.NewDocumentWithHash([]byte(docBody), prevDocHash)
+	doc.Metadata.Set(systemIdHeader, testSystemId)
+
+	rw := &mockRW{}
+	rw.On("ReadVersion", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, prevDocHash).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id/__history/:hash", testTable), ReadVersion(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s/__history/%s", testTable, testKey, prevDocHash), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Equal(t, docBody, string(body), "response body")
+	assert.Equal(t, prevDocHash, actual.Header.Get(documentHashHeader))
+	assert.Equal(t, testSystemId, actual.Header.Get(systemIdHeader))
+
+	rw.AssertExpectations(t)
+}
+
+func TestReadVersionNotFound(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("ReadVersion", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, prevDocHash).Return(db.Document{}, sql.ErrNoRows)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id/__history/:hash", testTable), ReadVersion(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s/__history/%s", testTable, testKey, prevDocHash), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusNotFound, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"No document found."}`, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestRestore(t *testing.T) {
+	docMatcher := mock.MatchedBy(func(doc db.Document) bool {
+		_, hasContentType := doc.Metadata["content-type"]
+		_, hasTimestamp := doc.Metadata["_timestamp"]
+		return doc.Body == nil && doc.Metadata["x-request-id"] == testTxId && hasTimestamp && !hasContentType
+	})
+
+	rw := &mockRW{}
+	rw.On("Restore", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, prevDocHash, docMatcher, map[string]string{"id": testKey, "hash": prevDocHash}, "").Return(db.Updated, docHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/:id/__history/:hash/restore", testTable), Restore(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/%s/__history/%s/restore", testTable, testKey, prevDocHash), nil)
+	req.Header.Set("X-Request-Id", testTxId)
+	req.Header.Set("Content-Type", "application/json")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+
+	rw.AssertExpectations(t)
+}
+
+func TestRestoreDeletedDocument(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Restore", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, prevDocHash, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey, "hash": prevDocHash}, db.NoDocumentHash).Return(db.Created, prevDocHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/:id/__history/:hash/restore", testTable), Restore(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/%s/__history/%s/restore", testTable, testKey, prevDocHash), nil)
+	req.Header.Set("If-None-Match", "*")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusCreated, actual.StatusCode, "HTTP status")
+	assert.Equal(t, prevDocHash, actual.Header.Get(documentHashHeader))
+
+	rw.AssertExpectations(t)
+}
+
+func TestRestoreVersionNotFound(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Restore", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, prevDocHash, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey, "hash": prevDocHash}, "").Return(db.Updated, "", sql.ErrNoRows)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/:id/__history/:hash/restore", testTable), Restore(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/%s/__history/%s/restore", testTable, testKey, prevDocHash), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusNotFound, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"No document version found."}`, string(body), "response body")
+
+	rw.AssertExpectations(t)
+}
+
+func TestRestoreConflict(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Restore", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, prevDocHash, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey, "hash": prevDocHash}, prevDocHash).Return(db.Updated, "", &db.ConflictError{CurrentHash: docHash})
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/:id/__history/:hash/restore", testTable), Restore(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/%s/__history/%s/restore", testTable, testKey, prevDocHash), nil)
+	req.Header.Set(previousDocumentHashHeader, prevDocHash)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusPreconditionFailed, actual.StatusCode, "HTTP status")
+	assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+
+	rw.AssertExpectations(t)
+}
@@ -0,0 +1,40 @@
+package resources
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/husobee/vestigo"
+)
+
+type keyParamsKey struct{}
+
+// KeyParams sets the route parameters whose values, in primary key order, make up the key of a document.
+// Without it, a document is identified by the :id route parameter.
+func KeyParams(names []string, next http.HandlerFunc) http.HandlerFunc {
+	if len(names) == 1 && names[0] == "id" {
+		return next
+	}
+	return func(writer http.ResponseWriter, request *http.Request) {
+		next(writer, request.WithContext(context.WithValue(request.Context(), keyParamsKey{}, names)))
+	}
+}
+
+// keyParams returns the names of the route parameters identifying the document of a request
+func keyParams(request *http.Request) []string {
+	if names, ok := request.Context().Value(keyParamsKey{}).([]string); ok {
+		return names
+	}
+	return []string{"id"}
+}
+
+// documentKey returns the key of the document of a request, joining the values of a composite primary key
+func documentKey(request *http.Request) string {
+	names := keyParams(request)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = vestigo.Param(request, name)
+	}
+	return db.JoinKey(values)
+}
@@ -0,0 +1,114 @@
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/Financial-Times/generic-rw-aurora/expression"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var compositeKeyParams = []string{"uuid", "platform"}
+
+func TestReadCompositeKey(t *testing.T) {
+	doc := db.NewDocument([]byte(docBody))
+	doc.Hash = docHash
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey+"/web").Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get("/content/:uuid/annotations/:platform", KeyParams(compositeKeyParams, Read(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/content/%s/annotations/web", testKey), nil)
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestWriteCompositeKey(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey+"/web", mock.AnythingOfType("db.Document"),
+		map[string]string{"uuid": testKey, "platform": "web"}, "").Return(db.Created, docHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Put("/content/:uuid/annotations/:platform", KeyParams(compositeKeyParams, Write(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/content/%s/annotations/web", testKey), strings.NewReader(docBody))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Result().StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestCreateCompositeKey(t *testing.T) {
+	id, err := expression.Parse("$.foo")
+	require.NoError(t, err)
+
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey+"/bar", mock.AnythingOfType("db.Document"),
+		map[string]string{"uuid": testKey, "id": "bar"}, db.NoDocumentHash).Return(db.Created, docHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Post("/content/:uuid/annotations", KeyParams([]string{"uuid", "id"}, Create(rw, testTable, "/content/:uuid/annotations/:id", id, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/content/%s/annotations", testKey), strings.NewReader(docBody))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusCreated, actual.StatusCode, "HTTP status")
+	assert.Equal(t, fmt.Sprintf("/content/%s/annotations/bar", testKey), actual.Header.Get("Location"))
+	rw.AssertExpectations(t)
+}
+
+func TestBulkWriteCompositeKey(t *testing.T) {
+	item := db.BulkWriteItem{Key: testKey + "/web", Document: db.NewDocument([]byte(docBody)), Params: map[string]string{"uuid": testKey, "platform": "web"}}
+	results := []db.BulkWriteResult{{Key: testKey + "/web", Status: db.Created, Hash: docHash}}
+
+	rw := &mockRW{}
+	rw.On("BulkWrite", mock.AnythingOfType("*context.timerCtx"), testTable, mock.MatchedBy(matchBulkWriteItems(item))).Return(results, nil)
+
+	router := vestigo.NewRouter()
+	router.Post("/annotations/__bulk-write", KeyParams(compositeKeyParams, BulkWrite(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/annotations/__bulk-write", strings.NewReader(fmt.Sprintf(`[{"key":"%s/web","body":%s}]`, testKey, docBody)))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestBulkWriteIncompleteCompositeKey(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Post("/annotations/__bulk-write", KeyParams(compositeKeyParams, BulkWrite(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/annotations/__bulk-write", strings.NewReader(fmt.Sprintf(`[{"key":"%s","body":%s}]`, testKey, docBody)))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"document 0 must have a key of 2 values separated by /"}`, string(body))
+	rw.AssertNotCalled(t, "BulkWrite", mock.Anything, mock.Anything, mock.Anything)
+}
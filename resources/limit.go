@@ -0,0 +1,93 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// rejectedBodySizes records the sizes of the request bodies rejected for exceeding the limit of their path.
+// The size of a body without a Content-Length is only known to exceed the limit, so the limit is recorded for it.
+var rejectedBodySizes = metrics.GetOrRegisterHistogram("rejected-body-sizes", metrics.DefaultRegistry, metrics.NewExpDecaySample(1028, 0.015))
+
+// BodyTooLargeError is returned when reading a request body exceeding the limit of its path
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("Request body exceeds the limit of %d bytes", e.Limit)
+}
+
+// LimitBody rejects a request whose body is larger than the limit with a 413 Request Entity Too Large.
+// A request declaring its length is rejected before its body is read; any other body is read up to the limit only.
+func LimitBody(limit int64, next http.HandlerFunc) http.HandlerFunc {
+	if limit <= 0 {
+		return next
+	}
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.ContentLength > limit {
+			rejectedBodySizes.Update(request.ContentLength)
+			writeBodyTooLarge(writer, &BodyTooLargeError{Limit: limit})
+			return
+		}
+
+		request.Body = &limitedBody{body: http.MaxBytesReader(writer, request.Body, limit), limit: limit}
+		next(writer, request)
+	}
+}
+
+// limitedBody reports a body exceeding its limit as a *BodyTooLargeError
+type limitedBody struct {
+	body  io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		rejectedBodySizes.Update(b.limit)
+		return n, &BodyTooLargeError{Limit: b.limit}
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// writeBodyReadError responds to a request whose body could not be read
+func writeBodyReadError(writer http.ResponseWriter, err error) {
+	switch err := err.(type) {
+	case *BodyTooLargeError:
+		writeBodyTooLarge(writer, err)
+	case *ContentEncodingError:
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+	default:
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+	}
+}
+
+// isBodyReadError returns whether an error decoding a request body was raised by reading the body, rather than by its content
+func isBodyReadError(err error) bool {
+	switch err.(type) {
+	case *BodyTooLargeError, *ContentEncodingError:
+		return true
+	}
+	return false
+}
+
+func writeBodyTooLarge(writer http.ResponseWriter, err *BodyTooLargeError) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusRequestEntityTooLarge)
+	json.NewEncoder(writer).Encode(map[string]interface{}{"message": err.Error(), "limit": err.Limit})
+}
@@ -0,0 +1,123 @@
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// unsizedReader hides the length of a body, as for a chunked request
+type unsizedReader struct {
+	*strings.Reader
+}
+
+func TestWriteWithinBodyLimit(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, "").Return(db.Created, docHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), LimitBody(int64(len(docBody)), Write(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), unsizedReader{strings.NewReader(docBody)})
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Result().StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestWriteBodyTooLarge(t *testing.T) {
+	tests := []struct {
+		name string
+		body func() *http.Request
+	}{
+		{"declared length", func() *http.Request {
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+			return req
+		}},
+		{"unknown length", func() *http.Request {
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), unsizedReader{strings.NewReader(docBody)})
+			return req
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rw := &mockRW{}
+			rejected := rejectedBodySizes.Count()
+
+			router := vestigo.NewRouter()
+			router.Put(fmt.Sprintf("/%s/:id", testTable), LimitBody(8, Write(rw, testTable, testDefaultTimeout)))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, test.body())
+			actual := w.Result()
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, actual.StatusCode, "HTTP status")
+			body, _ := ioutil.ReadAll(actual.Body)
+			assert.JSONEq(t, `{"message":"Request body exceeds the limit of 8 bytes","limit":8}`, string(body))
+			assert.Equal(t, rejected+1, rejectedBodySizes.Count(), "rejected body sizes")
+			rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPatchBodyTooLarge(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Patch(fmt.Sprintf("/%s/:id", testTable), LimitBody(8, Patch(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/%s/%s", testTable, testKey), unsizedReader{strings.NewReader(`{"foo":"baz"}`)})
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Result().StatusCode, "HTTP status")
+	rw.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBulkWriteBodyTooLarge(t *testing.T) {
+	for _, contentType := range []string{"application/json", ndjsonContentType} {
+		t.Run(contentType, func(t *testing.T) {
+			rw := &mockRW{}
+
+			router := vestigo.NewRouter()
+			router.Post(fmt.Sprintf("/%s/__bulk-write", testTable), LimitBody(16, BulkWrite(rw, testTable, testDefaultTimeout)))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-write", testTable), unsizedReader{strings.NewReader(fmt.Sprintf(`[{"key":"1","body":%s}]`, docBody))})
+			req.Header.Set("Content-Type", contentType)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Result().StatusCode, "HTTP status")
+			rw.AssertNotCalled(t, "BulkWrite", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestBulkReadBodyTooLarge(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-read", testTable), LimitBody(8, BulkRead(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-read", testTable), unsizedReader{strings.NewReader(`["1","2","3"]`)})
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Result().StatusCode, "HTTP status")
+	rw.AssertNotCalled(t, "ReadMany", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,131 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultMediaType = "application/json"
+
+// producedMediaTypesKey holds the media types produced by the path of a read, if it has any
+type producedMediaTypesKey struct{}
+
+// Consumes rejects a request whose body is not of one of the accepted media types with a 415 Unsupported Media Type.
+// Without any accepted media type, every body is accepted.
+func Consumes(types []string, next http.HandlerFunc) http.HandlerFunc {
+	if len(types) == 0 {
+		return next
+	}
+	return func(writer http.ResponseWriter, request *http.Request) {
+		contentType := request.Header.Get("Content-Type")
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil {
+			for _, accepted := range types {
+				if mediaTypeMatches(accepted, mediaType) {
+					next(writer, request)
+					return
+				}
+			}
+		}
+
+		message := fmt.Sprintf("Unsupported media type %s, expected one of: %s", contentType, strings.Join(types, ", "))
+		if contentType == "" {
+			message = fmt.Sprintf("Missing Content-Type, expected one of: %s", strings.Join(types, ", "))
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(writer).Encode(map[string]string{"message": message})
+	}
+}
+
+// Produces rejects a read whose Accept header matches none of the produced media types with a 406 Not Acceptable.
+// The first produced media type is the type of the documents which do not have their own, instead of application/json.
+// Without any produced media type, the Accept header is not checked, and every document is served as its own media type.
+func Produces(types []string, next http.HandlerFunc) http.HandlerFunc {
+	if len(types) == 0 {
+		return next
+	}
+	return func(writer http.ResponseWriter, request *http.Request) {
+		accept := request.Header.Get("Accept")
+		for _, produced := range types {
+			if isAcceptable(accept, produced) {
+				next(writer, request.WithContext(context.WithValue(request.Context(), producedMediaTypesKey{}, types)))
+				return
+			}
+		}
+		writeNotAcceptable(writer, types)
+	}
+}
+
+// documentMediaType returns the media type of a document read, which is either in its metadata or the default of its path
+func documentMediaType(request *http.Request, metadata map[string]string) string {
+	for k, v := range metadata {
+		if http.CanonicalHeaderKey(k) == "Content-Type" && v != "" {
+			return v
+		}
+	}
+	if produced := producedMediaTypes(request); len(produced) > 0 {
+		return produced[0]
+	}
+	return defaultMediaType
+}
+
+// producedMediaTypes returns the media types produced by the path of a read, which are negotiated with its Accept header
+func producedMediaTypes(request *http.Request) []string {
+	produced, _ := request.Context().Value(producedMediaTypesKey{}).([]string)
+	return produced
+}
+
+func writeNotAcceptable(writer http.ResponseWriter, available []string) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusNotAcceptable)
+	json.NewEncoder(writer).Encode(map[string]string{"message": fmt.Sprintf("Not acceptable, the document is available as: %s", strings.Join(available, ", "))})
+}
+
+// isAcceptable returns whether a media type is acceptable according to an Accept header, the most specific matching range deciding.
+// Every media type is acceptable without an Accept header.
+func isAcceptable(accept string, contentType string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	specificity := -1
+	quality := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		accepted, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !mediaTypeMatches(accepted, mediaType) {
+			continue
+		}
+		s := strings.Count(accepted, "*")
+		if s = 2 - s; s <= specificity {
+			continue
+		}
+		specificity = s
+		quality = 1.0
+		if q, found := params["q"]; found {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				quality = 0
+			}
+		}
+	}
+	return quality > 0
+}
+
+// mediaTypeMatches returns whether a media type matches a media range such as text/* or */*
+func mediaTypeMatches(mediaRange string, mediaType string) bool {
+	mediaRange = strings.ToLower(mediaRange)
+	mediaType = strings.ToLower(mediaType)
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	return strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+}
@@ -0,0 +1,198 @@
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const htmlBody = "<p>draft</p>"
+
+func TestIsAcceptable(t *testing.T) {
+	tests := []struct {
+		accept     string
+		mediaType  string
+		acceptable bool
+	}{
+		{"", "text/html", true},
+		{"text/html", "text/html; charset=utf-8", true},
+		{"application/json", "text/html", false},
+		{"text/*", "text/html", true},
+		{"*/*", "text/html", true},
+		{"application/json, text/html;q=0.5", "text/html", true},
+		{"*/*, text/html;q=0", "text/html", false},
+		{"text/*;q=0, text/html", "text/html", true},
+		{"TEXT/HTML", "text/html", true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.acceptable, isAcceptable(test.accept, test.mediaType), "%s acceptable as %s", test.mediaType, test.accept)
+	}
+}
+
+func TestWriteSupportedMediaType(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, "").Return(db.Created, docHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), Consumes([]string{"application/json", "text/*"}, Write(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(htmlBody))
+	req.Header.Set("Content-Type", "text/html; charset=utf-8")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Result().StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestWriteUnsupportedMediaType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		message     string
+	}{
+		{"application/xml", `{"message":"Unsupported media type application/xml, expected one of: application/json, text/*"}`},
+		{"", `{"message":"Missing Content-Type, expected one of: application/json, text/*"}`},
+	}
+
+	for _, test := range tests {
+		rw := &mockRW{}
+
+		router := vestigo.NewRouter()
+		router.Put(fmt.Sprintf("/%s/:id", testTable), Consumes([]string{"application/json", "text/*"}, Write(rw, testTable, testDefaultTimeout)))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+
+		router.ServeHTTP(w, req)
+		actual := w.Result()
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, actual.StatusCode, "HTTP status")
+		body, _ := ioutil.ReadAll(actual.Body)
+		assert.JSONEq(t, test.message, string(body))
+		rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	}
+}
+
+func TestReadStoredMediaType(t *testing.T) {
+	doc := db.NewDocument([]byte(htmlBody))
+	doc.Hash = docHash
+	doc.Metadata.Set("Content-Type", "text/html")
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), Produces([]string{"application/json", "text/html"}, Read(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set("Accept", "text/html, application/json;q=0.9")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "text/html", actual.Header.Get("Content-Type"), "content type")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Equal(t, htmlBody, string(body), "response body")
+}
+
+func TestReadDefaultMediaType(t *testing.T) {
+	doc := db.NewDocument([]byte(htmlBody))
+	doc.Hash = docHash
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), Produces([]string{"text/html"}, Read(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "text/html", actual.Header.Get("Content-Type"), "content type")
+}
+
+func TestReadNotAcceptable(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), Produces([]string{"application/json", "text/html"}, Read(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set("Accept", "application/xml")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusNotAcceptable, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"Not acceptable, the document is available as: application/json, text/html"}`, string(body))
+	rw.AssertNotCalled(t, "Read", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReadStoredMediaTypeNotAcceptable(t *testing.T) {
+	doc := db.NewDocument([]byte(htmlBody))
+	doc.Hash = docHash
+	doc.Metadata.Set("Content-Type", "text/html")
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), Produces([]string{"application/json", "text/html"}, Read(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set("Accept", "application/json")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusNotAcceptable, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content type")
+	assert.Empty(t, actual.Header.Get(documentHashHeader))
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"Not acceptable, the document is available as: text/html"}`, string(body))
+}
+
+func TestReadWithoutProducesIgnoresAccept(t *testing.T) {
+	doc := db.NewDocument([]byte(docBody))
+	doc.Hash = docHash
+
+	rw := &mockRW{}
+	rw.On("Read", mock.AnythingOfType("*context.timerCtx"), testTable, testKey).Return(doc, nil)
+
+	router := vestigo.NewRouter()
+	router.Get(fmt.Sprintf("/%s/:id", testTable), Produces(nil, Read(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/%s/%s", testTable, testKey), nil)
+	req.Header.Set("Accept", "text/html")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "application/json", actual.Header.Get("Content-Type"), "content type")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Equal(t, docBody, string(body), "response body")
+}
@@ -0,0 +1,171 @@
+package resources
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	tidutils "github.com/Financial-Times/transactionid-utils-go"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/husobee/vestigo"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+var acceptedPatchContentTypes = strings.Join([]string{mergePatchContentType, jsonPatchContentType}, ", ")
+
+func Patch(service db.RWService, table string, timeout time.Duration) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+
+		params := make(map[string]string)
+		for _, p := range vestigo.ParamNames(request) {
+			params[p[1:]] = vestigo.Param(request, p[1:])
+		}
+		id := documentKey(request)
+
+		writer.Header().Set("Content-Type", "application/json")
+
+		previousDocHash, err := previousDocumentHash(request)
+		if err != nil {
+			writeInvalidPrecondition(writer, err)
+			return
+		}
+
+		patchBody, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			writeBodyReadError(writer, err)
+			return
+		}
+
+		patch, err := newPatchFunc(request.Header.Get("Content-Type"), patchBody)
+		if err != nil {
+			if err == errUnsupportedPatchType {
+				writer.Header().Set("Accept-Patch", acceptedPatchContentTypes)
+				writer.WriteHeader(http.StatusUnsupportedMediaType)
+			} else {
+				writer.WriteHeader(http.StatusBadRequest)
+			}
+			json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+			return
+		}
+
+		// the patched document is validated before it is written
+		applyPatch := func(body []byte) ([]byte, error) {
+			patched, err := patch(body)
+			if err != nil {
+				return nil, err
+			}
+			return patched, validateDocument(request, patched)
+		}
+
+		// start the endpoint timer after we consume the http body
+		txid := tidutils.GetTransactionIDFromRequest(request)
+		ctx, cancelFunc := context.WithTimeout(tidutils.TransactionAwareContext(context.Background(), txid), timeout)
+		defer cancelFunc()
+
+		responseCh := make(chan statusHashTuple)
+		errorCh := make(chan error)
+
+		go func(responseCh chan statusHashTuple, errorCh chan error) {
+			doc := db.NewDocument(nil)
+			for k := range request.Header {
+				v := request.Header.Get(k)
+				doc.Metadata.Set(strings.ToLower(k), v)
+			}
+			// the patched document is JSON, whatever the media type of the patch
+			doc.Metadata.Set("content-type", "application/json")
+			doc.Metadata.Set("_timestamp", time.Now().UTC().Format("2006-01-02T15:04:05.000Z"))
+
+			status, hash, err := service.Patch(ctx, table, id, applyPatch, doc, params, previousDocHash)
+
+			if err != nil {
+				errorCh <- err
+				return
+			}
+			responseCh <- statusHashTuple{status, hash}
+		}(responseCh, errorCh)
+
+		patchLog := log.WithFields(log.Fields{tidutils.TransactionIDKey: txid, "key": id, "table": table})
+
+		select {
+		case <-ctx.Done():
+			patchLog.Error("Document patch request timed out")
+			writer.WriteHeader(http.StatusGatewayTimeout)
+			json.NewEncoder(writer).Encode(map[string]string{"message": "document patch request timed out"})
+
+		case err := <-errorCh:
+			switch e := err.(type) {
+			case *db.ConflictError:
+				patchLog.Warn("Document patch rejected due to a hash conflict")
+				writePreconditionFailed(writer, e)
+				return
+			case *db.PatchError:
+				writer.WriteHeader(http.StatusUnprocessableEntity)
+				if schemaErr, ok := e.Err.(*SchemaError); ok {
+					patchLog.Warn("Patched document does not match the schema")
+					json.NewEncoder(writer).Encode(schemaErrorBody{Message: schemaErr.Message, Violations: schemaErr.Violations})
+					return
+				}
+			case *db.ValueError, *db.MissingValuesError:
+				writer.WriteHeader(http.StatusBadRequest)
+			default:
+				if err == sql.ErrNoRows {
+					patchLog.Info("Document is missing")
+					writer.WriteHeader(http.StatusNotFound)
+					json.NewEncoder(writer).Encode(map[string]string{"message": errNotFound})
+					return
+				}
+				writer.WriteHeader(http.StatusInternalServerError)
+			}
+			json.NewEncoder(writer).Encode(map[string]string{"message": err.Error()})
+
+		case statusHashTuple := <-responseCh:
+			writer.Header().Set(documentHashHeader, statusHashTuple.hash)
+			writer.Header().Set(etagHeader, entityTag(statusHashTuple.hash))
+			if statusHashTuple.status == db.Unchanged {
+				writer.Header().Set(documentUnchangedHeader, "true")
+				patchLog.Info("Document is unchanged")
+			} else {
+				patchLog.Info("Document has been patched")
+			}
+			writer.WriteHeader(http.StatusOK)
+		}
+	}
+}
+
+var errUnsupportedPatchType = fmt.Errorf("patch media type must be one of %s", acceptedPatchContentTypes)
+
+// newPatchFunc returns a function applying the patch, according to its media type, to a JSON document
+func newPatchFunc(contentType string, patchBody []byte) (func([]byte) ([]byte, error), error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case mergePatchContentType:
+		if !json.Valid(patchBody) {
+			return nil, fmt.Errorf("merge patch is not valid JSON")
+		}
+		return func(body []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(body, patchBody)
+		}, nil
+
+	case jsonPatchContentType:
+		patch, err := jsonpatch.DecodePatch(patchBody)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON patch: %v", err)
+		}
+		return patch.Apply, nil
+
+	default:
+		return nil, errUnsupportedPatchType
+	}
+}
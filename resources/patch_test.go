@@ -0,0 +1,133 @@
+package resources
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		patch       string
+	}{
+		{"merge patch", "application/merge-patch+json", `{"foo":"baz","qux":1}`},
+		{"JSON patch", "application/json-patch+json; charset=utf-8", `[{"op":"replace","path":"/foo","value":"baz"},{"op":"add","path":"/qux","value":1}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var patched []byte
+			rw := &mockRW{}
+			rw.On("Patch", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("func([]uint8) ([]uint8, error)"), mock.MatchedBy(func(doc db.Document) bool {
+				return doc.Metadata["content-type"] == "application/json"
+			}), map[string]string{"id": testKey}, prevDocHash).Run(func(args mock.Arguments) {
+				var err error
+				patched, err = args.Get(3).(func([]byte) ([]byte, error))([]byte(docBody))
+				require.NoError(t, err)
+			}).Return(db.Updated, docHash, nil)
+
+			router := vestigo.NewRouter()
+			router.Patch(fmt.Sprintf("/%s/:id", testTable), Patch(rw, testTable, testDefaultTimeout))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(test.patch))
+			req.Header.Set("Content-Type", test.contentType)
+			req.Header.Set(previousDocumentHashHeader, prevDocHash)
+
+			router.ServeHTTP(w, req)
+			actual := w.Result()
+
+			assert.Equal(t, http.StatusOK, actual.StatusCode, "HTTP status")
+			assert.Equal(t, docHash, actual.Header.Get(documentHashHeader))
+			assert.JSONEq(t, `{"foo":"baz","qux":1}`, string(patched), "patched document")
+
+			rw.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPatchUnsupportedMediaType(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Patch(fmt.Sprintf("/%s/:id", testTable), Patch(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, actual.StatusCode, "HTTP status")
+	assert.Equal(t, "application/merge-patch+json, application/json-patch+json", actual.Header.Get("Accept-Patch"))
+
+	rw.AssertExpectations(t)
+}
+
+func TestPatchInvalidPatch(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Patch(fmt.Sprintf("/%s/:id", testTable), Patch(rw, testTable, testDefaultTimeout))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(`{"op":"add"}`))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+
+	rw.AssertExpectations(t)
+}
+
+func TestPatchErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{"missing document", sql.ErrNoRows, http.StatusNotFound},
+		{"conflict", &db.ConflictError{CurrentHash: docHash}, http.StatusPreconditionFailed},
+		{"patch failure", &db.PatchError{Err: fmt.Errorf("test failed")}, http.StatusUnprocessableEntity},
+		{"unexpected error", fmt.Errorf("Some unexpected error"), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rw := &mockRW{}
+			rw.On("Patch", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.Anything, mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, "").Return(db.Updated, "", test.err)
+
+			router := vestigo.NewRouter()
+			router.Patch(fmt.Sprintf("/%s/:id", testTable), Patch(rw, testTable, testDefaultTimeout))
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(`{"foo":null}`))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+
+			router.ServeHTTP(w, req)
+			actual := w.Result()
+
+			assert.Equal(t, test.expectedStatus, actual.StatusCode, "HTTP status")
+			var errorResponse map[string]string
+			json.NewDecoder(actual.Body).Decode(&errorResponse)
+			assert.NotEmpty(t, errorResponse["message"])
+
+			rw.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/husobee/vestigo"
+)
+
+// QueryParams exposes the declared query string parameters of a request to the column expressions, as :query.name route parameters.
+// A request missing any required parameter is rejected with a 400 Bad Request.
+func QueryParams(query config.QueryMapping, next http.HandlerFunc) http.HandlerFunc {
+	declared := query.Params()
+	return func(writer http.ResponseWriter, request *http.Request) {
+		values := request.URL.Query()
+
+		var missing []string
+		for _, name := range query.Required {
+			if values.Get(name) == "" {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			writer.Header().Set("Content-Type", "application/json")
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(map[string]string{"message": fmt.Sprintf("Missing required query parameter(s): %s", strings.Join(missing, ", "))})
+			return
+		}
+
+		for _, name := range declared {
+			if _, found := values[name]; found {
+				vestigo.AddParam(request, config.QueryParamPrefix+name, values.Get(name))
+			}
+		}
+
+		next(writer, request)
+	}
+}
+
+// StripRouteParams removes the parameters named like route parameters (:name) from the query string of a request before it is routed.
+// The router carries route parameters in the query string, and reads the first value of each, so a client must not be able to supply them.
+func StripRouteParams(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		values := request.URL.Query()
+		stripped := false
+		for name := range values {
+			if strings.HasPrefix(name, ":") {
+				values.Del(name)
+				stripped = true
+			}
+		}
+		if stripped {
+			request.URL.RawQuery = values.Encode()
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
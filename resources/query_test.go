@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testQuery = config.QueryMapping{Allowed: []string{"brand"}, Required: []string{"edition"}}
+
+func TestWriteWithQueryParams(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"),
+		map[string]string{"id": testKey, "query.brand": "ft", "query.edition": "uk"}, "").Return(db.Created, docHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), QueryParams(testQuery, Write(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s?brand=ft&edition=uk&other=ignored&:query.other=spoofed", testTable, testKey), strings.NewReader(docBody))
+
+	StripRouteParams(router).ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusCreated, actual.StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestStripRouteParams(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"),
+		map[string]string{"id": testKey, "query.brand": "ft", "query.edition": "uk"}, "").Return(db.Created, docHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), QueryParams(testQuery, Write(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s?:id=other&%%3Aquery.brand=spoofed&brand=ft&edition=uk", testTable, testKey), strings.NewReader(docBody))
+
+	StripRouteParams(router).ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusCreated, actual.StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestWriteWithoutOptionalQueryParam(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("db.Document"),
+		map[string]string{"id": testKey, "query.edition": "uk"}, "").Return(db.Created, docHash, nil)
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), QueryParams(testQuery, Write(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s?edition=uk", testTable, testKey), strings.NewReader(docBody))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusCreated, actual.StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestWriteMissingRequiredQueryParam(t *testing.T) {
+	rw := &mockRW{}
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), QueryParams(testQuery, Write(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s?brand=ft&edition=", testTable, testKey), strings.NewReader(docBody))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"Missing required query parameter(s): edition"}`, string(body))
+	rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,91 @@
+package resources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+)
+
+// schemaErrorBody lists the violations of the schema by a document
+type schemaErrorBody struct {
+	Message    string   `json:"message"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// ValidateSchema validates the body of a request against the JSON Schema of its path before it is written.
+// A body that is not JSON, or violates the schema, is rejected with a 400 Bad Request. Without a schema, every body is accepted.
+func ValidateSchema(schema *config.Schema, next http.HandlerFunc) http.HandlerFunc {
+	if schema == nil {
+		return next
+	}
+	return func(writer http.ResponseWriter, request *http.Request) {
+		docBody, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			writeBodyReadError(writer, err)
+			return
+		}
+
+		violations, err := schema.Validate(docBody)
+		if err != nil || len(violations) > 0 {
+			body := schemaErrorBody{Message: "Document does not match the schema", Violations: violations}
+			if err != nil {
+				body.Message = "Document is not valid JSON"
+			}
+			writer.Header().Set("Content-Type", "application/json")
+			writer.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(writer).Encode(body)
+			return
+		}
+
+		request.Body = ioutil.NopCloser(bytes.NewReader(docBody))
+		next(writer, request)
+	}
+}
+
+// SchemaError is returned when a document, which is only known once a request has been read, does not match the schema of its path
+type SchemaError struct {
+	Message    string
+	Violations []string
+}
+
+func (e *SchemaError) Error() string {
+	if len(e.Violations) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Message, strings.Join(e.Violations, "; "))
+}
+
+type documentSchemaKey struct{}
+
+// DocumentSchema sets the JSON Schema of the path for the documents of PATCH and bulk write requests, which are validated as they are written.
+// Without a schema, every document is accepted.
+func DocumentSchema(schema *config.Schema, next http.HandlerFunc) http.HandlerFunc {
+	if schema == nil {
+		return next
+	}
+	return func(writer http.ResponseWriter, request *http.Request) {
+		next(writer, request.WithContext(context.WithValue(request.Context(), documentSchemaKey{}, schema)))
+	}
+}
+
+// validateDocument validates a document against the schema set by DocumentSchema, if any, returning a *SchemaError if it does not match
+func validateDocument(request *http.Request, docBody []byte) error {
+	schema, ok := request.Context().Value(documentSchemaKey{}).(*config.Schema)
+	if !ok {
+		return nil
+	}
+	violations, err := schema.Validate(docBody)
+	if err != nil {
+		return &SchemaError{Message: "Document is not valid JSON"}
+	}
+	if len(violations) > 0 {
+		return &SchemaError{Message: "Document does not match the schema", Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/husobee/vestigo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "properties": {"foo": {"type": "string"}, "count": {"type": "integer"}},
+  "required": ["foo"]
+}`
+
+func schemaRouter(t *testing.T, rw db.RWService) *vestigo.Router {
+	schema, err := config.NewSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	router := vestigo.NewRouter()
+	router.Put(fmt.Sprintf("/%s/:id", testTable), ValidateSchema(schema, Write(rw, testTable, testDefaultTimeout)))
+	return router
+}
+
+func TestWriteValidDocument(t *testing.T) {
+	rw := &mockRW{}
+	rw.On("Write", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.MatchedBy(func(doc db.Document) bool { return string(doc.Body) == docBody }), map[string]string{"id": testKey}, "").Return(db.Created, docHash, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(docBody))
+
+	schemaRouter(t, rw).ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusCreated, actual.StatusCode, "HTTP status")
+	rw.AssertExpectations(t)
+}
+
+func TestWriteDocumentViolatingSchema(t *testing.T) {
+	rw := &mockRW{}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(`{"count":1.5}`))
+
+	schemaRouter(t, rw).ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"Document does not match the schema","violations":["(root): foo is required","count: Invalid type. Expected: integer, given: number"]}`, string(body))
+	rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWriteDocumentNotJSON(t *testing.T) {
+	rw := &mockRW{}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader("not json"))
+
+	schemaRouter(t, rw).ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusBadRequest, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"Document is not valid JSON"}`, string(body))
+	rw.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestValidateSchemaWithoutSchema(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+	assert.Equal(t, fmt.Sprintf("%p", handler), fmt.Sprintf("%p", ValidateSchema(nil, handler)))
+}
+
+func TestPatchDocumentViolatingSchema(t *testing.T) {
+	schema, err := config.NewSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	patchErr := &db.PatchError{}
+	rw := &mockRW{}
+	rw.On("Patch", mock.AnythingOfType("*context.timerCtx"), testTable, testKey, mock.AnythingOfType("func([]uint8) ([]uint8, error)"), mock.AnythingOfType("db.Document"), map[string]string{"id": testKey}, "").Run(func(args mock.Arguments) {
+		_, patchErr.Err = args.Get(3).(func([]byte) ([]byte, error))([]byte(docBody))
+	}).Return(db.Updated, "", patchErr)
+
+	router := vestigo.NewRouter()
+	router.Patch(fmt.Sprintf("/%s/:id", testTable), DocumentSchema(schema, Patch(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/%s/%s", testTable, testKey), strings.NewReader(`{"foo":null}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusUnprocessableEntity, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.JSONEq(t, `{"message":"Document does not match the schema","violations":["(root): foo is required"]}`, string(body))
+}
+
+func TestBulkWriteDocumentViolatingSchema(t *testing.T) {
+	schema, err := config.NewSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	rw := &mockRW{}
+	rw.On("BulkWrite", mock.AnythingOfType("*context.timerCtx"), testTable, mock.MatchedBy(func(items []db.BulkWriteItem) bool {
+		return len(items) == 2 && items[0].Err == nil && items[1].Err != nil
+	})).Return([]db.BulkWriteResult{
+		{Key: "1", Err: &db.RolledBackError{FailedKey: "2"}},
+		{Key: "2", Err: &SchemaError{Message: "Document does not match the schema", Violations: []string{"(root): foo is required"}}},
+	}, nil)
+
+	router := vestigo.NewRouter()
+	router.Post(fmt.Sprintf("/%s/__bulk-write", testTable), DocumentSchema(schema, BulkWrite(rw, testTable, testDefaultTimeout)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/%s/__bulk-write", testTable), strings.NewReader(fmt.Sprintf(`[{"key":"1","body":%s},{"key":"2","body":{"count":1}}]`, docBody)))
+
+	router.ServeHTTP(w, req)
+	actual := w.Result()
+
+	assert.Equal(t, http.StatusMultiStatus, actual.StatusCode, "HTTP status")
+	body, _ := ioutil.ReadAll(actual.Body)
+	assert.Contains(t, string(body), `{"key":"2","status":"error","message":"Document does not match the schema: (root): foo is required"}`)
+	rw.AssertExpectations(t)
+}
+
+func TestValidateDocument(t *testing.T) {
+	schema, err := config.NewSchema([]byte(testSchema))
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", "/", nil)
+	assert.NoError(t, validateDocument(req, []byte("not json")), "without a schema, every document is accepted")
+
+	DocumentSchema(schema, func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, validateDocument(r, []byte(docBody)))
+		assert.EqualError(t, validateDocument(r, []byte("not json")), "Document is not valid JSON")
+		assert.IsType(t, &SchemaError{}, validateDocument(r, []byte(`{"count":1}`)))
+	})(httptest.NewRecorder(), req)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/Financial-Times/generic-rw-aurora/db"
+	"github.com/Financial-Times/generic-rw-aurora/expression"
+	"github.com/Financial-Times/generic-rw-aurora/resources"
+)
+
+// route is an endpoint created for a path of the r/w configuration
+type route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+}
+
+// pathRoutes returns the endpoints created for a path of the r/w configuration
+func pathRoutes(path string, cfg config.Mapping, rw db.RWService, timeout time.Duration, maxBodySize config.ByteSize) []route {
+	bodyLimit := int64(cfg.BodySizeLimit(maxBodySize))
+	readDocument := func(read http.HandlerFunc) http.HandlerFunc {
+		return resources.EncodeResponse(resources.Produces(cfg.Produces, read))
+	}
+	writeBody := func(limit int64, write http.HandlerFunc) http.HandlerFunc {
+		return resources.DecodeBody(resources.LimitBody(limit, resources.QueryParams(cfg.Query, write)))
+	}
+	writeDocument := func(write http.HandlerFunc) http.HandlerFunc {
+		return writeBody(bodyLimit, resources.Consumes(cfg.Consumes, resources.ValidateSchema(cfg.DocumentSchema(), write)))
+	}
+
+	routes := []route{
+		{http.MethodGet, path, readDocument(resources.Read(rw, cfg.Table, timeout))},
+		{http.MethodPut, path, writeDocument(resources.Write(rw, cfg.Table, timeout))},
+		{http.MethodPatch, path, writeBody(bodyLimit, resources.DocumentSchema(cfg.DocumentSchema(), resources.Patch(rw, cfg.Table, timeout)))},
+	}
+	if cfg.AllowDelete {
+		routes = append(routes, route{http.MethodDelete, path, resources.Delete(rw, cfg.Table, timeout)})
+	}
+	if cfg.History {
+		routes = append(routes,
+			route{http.MethodGet, path + "/__history", resources.History(rw, cfg.Table, timeout)},
+			route{http.MethodGet, path + "/__history/:hash", readDocument(resources.ReadVersion(rw, cfg.Table, timeout))},
+			route{http.MethodPost, path + "/__history/:hash/restore", resources.QueryParams(cfg.Query, resources.Restore(rw, cfg.Table, timeout))},
+		)
+	}
+
+	collectionPath := cfg.CollectionPathFor(path)
+	if cfg.List.Enabled {
+		routes = append(routes, route{http.MethodGet, collectionPath, resources.List(rw, cfg.Table, timeout)})
+	}
+	if cfg.AllowBulkRead {
+		routes = append(routes, route{http.MethodPost, collectionPath + "/__bulk-read", resources.LimitBody(bodyLimit, resources.BulkRead(rw, cfg.Table, timeout))})
+	}
+	if cfg.Create.Enabled {
+		// the id expression is checked when the configuration is validated
+		if id, err := expression.Parse(cfg.Create.IDExpr()); err == nil {
+			routes = append(routes, route{http.MethodPost, collectionPath, writeDocument(resources.Create(rw, cfg.Table, path, id, timeout))})
+		}
+	}
+	if cfg.BulkWrite.Enabled {
+		routes = append(routes, route{http.MethodPost, collectionPath + "/__bulk-write", writeBody(int64(cfg.BulkWriteBodySizeLimit(maxBodySize)), resources.DocumentSchema(cfg.DocumentSchema(), resources.BulkWrite(rw, cfg.Table, cfg.BulkWriteTimeout(timeout))))})
+	}
+
+	keyParams := cfg.KeyParams()
+	for i := range routes {
+		routes[i].handler = resources.KeyParams(keyParams, routes[i].handler)
+	}
+	return routes
+}
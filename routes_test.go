@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Financial-Times/generic-rw-aurora/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathRoutesMatchConfigRoutes(t *testing.T) {
+	cfg := config.Mapping{
+		Table:         "things",
+		Columns:       map[string]config.Column{"uuid": {Expr: ":id"}, "body": {Expr: "$"}},
+		PrimaryKey:    config.PrimaryKey{"uuid"},
+		AllowDelete:   true,
+		History:       true,
+		List:          config.ListMapping{Enabled: true},
+		AllowBulkRead: true,
+		Create:        config.CreateMapping{Enabled: true},
+		BulkWrite:     config.BulkWriteMapping{Enabled: true},
+	}
+
+	var routes []config.Route
+	for _, r := range pathRoutes("/things/:id", cfg, nil, 0, 0) {
+		routes = append(routes, config.Route{Method: r.method, Path: r.path})
+	}
+	assert.Equal(t, cfg.Routes("/things/:id"), routes)
+}